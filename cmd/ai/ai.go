@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"stripper/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+type CacheOptions struct {
+	OutputDir string
+	OlderThan string
+	Model     string
+}
+
+func NewAICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ai",
+		Short: "Manage AI processing of crawled content",
+	}
+
+	cmd.AddCommand(newCacheCmd())
+
+	return cmd
+}
+
+func newCacheCmd() *cobra.Command {
+	opts := &CacheOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and purge cached AI results",
+		Long: `AI results are cached in the crawl database by content hash, model,
+prompt hash and provider, so unchanged pages are not sent to the model again.`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "Show AI cache statistics",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheStats(opts)
+		},
+	}
+
+	purge := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete cached AI results",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCachePurge(opts)
+		},
+	}
+	purge.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only purge results older than this duration (e.g., 720h)")
+	purge.Flags().StringVar(&opts.Model, "model", "", "Only purge results for this model")
+
+	cmd.AddCommand(stats, purge)
+
+	return cmd
+}
+
+// openDB opens the crawl database in the output directory
+func openDB(outputDir string) (*database.DB, error) {
+	dbPath := path.Join(path.Clean(outputDir), "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+func runCacheStats(opts *CacheOptions) error {
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := db.GetAICacheStats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache statistics: %w", err)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("AI cache is empty")
+		return nil
+	}
+
+	var entries, hits int
+	var bytes int64
+	fmt.Printf("%-12s %-30s %8s %8s %10s\n", "PROVIDER", "MODEL", "ENTRIES", "HITS", "SIZE")
+	for _, s := range stats {
+		fmt.Printf("%-12s %-30s %8d %8d %10s\n", s.Provider, s.Model, s.Entries, s.Hits, formatBytes(s.Bytes))
+		entries += s.Entries
+		hits += s.Hits
+		bytes += s.Bytes
+	}
+	fmt.Printf("%-12s %-30s %8d %8d %10s\n", "total", "", entries, hits, formatBytes(bytes))
+
+	return nil
+}
+
+func runCachePurge(opts *CacheOptions) error {
+	var before time.Time
+	if opts.OlderThan != "" {
+		age, err := time.ParseDuration(opts.OlderThan)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", opts.OlderThan, err)
+		}
+		before = time.Now().Add(-age)
+	}
+
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deleted, err := db.PurgeAICache(before, opts.Model)
+	if err != nil {
+		return fmt.Errorf("failed to purge cache: %w", err)
+	}
+
+	fmt.Printf("Purged %d cached AI results\n", deleted)
+	return nil
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,106 @@
+package ask
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+type AskOptions struct {
+	ConfigFile     string
+	OutputDir      string
+	Question       string
+	Sources        int
+	AIProvider     string
+	AIEndpoint     string
+	AIKey          string
+	AIModel        string
+	EmbeddingModel string
+}
+
+func NewAskCmd() *cobra.Command {
+	opts := &AskOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "ask [question]",
+		Short: "Ask a question about the archive",
+		Long: `Answer a question from the pages of an archive.
+
+The chunks closest to the question are retrieved from the index built by
+stripper embed and sent with the question to the configured AI provider. The
+answer cites its sources, which are listed with their URLs.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Question = strings.Join(args, " ")
+			return runAsk(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().IntVarP(&opts.Sources, "sources", "k", crawler.DefaultAskSources, "Number of chunks retrieved as context")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "", "AI model used to answer")
+	cmd.Flags().StringVar(&opts.EmbeddingModel, "embedding-model", "", "Embedding model (default: crawler.ai.embedding_model)")
+
+	return cmd
+}
+
+func runAsk(opts *AskOptions) error {
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"ai": map[string]interface{}{
+			"provider":        opts.AIProvider,
+			"endpoint":        opts.AIEndpoint,
+			"api_key":         opts.AIKey,
+			"model":           opts.AIModel,
+			"embedding_model": opts.EmbeddingModel,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	answer, err := crawler.Ask(db, crawler.AskOptions{
+		Sources: opts.Sources,
+		AI:      crawler.AIOptionsFromConfig(cfg),
+	}, opts.Question)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(strings.TrimSpace(answer.Text))
+	fmt.Println()
+	fmt.Println("Sources:")
+	for i, source := range answer.Sources {
+		if source.Heading != "" {
+			fmt.Printf("  [%d] %s (%s)\n", i+1, source.URL, source.Heading)
+		} else {
+			fmt.Printf("  [%d] %s\n", i+1, source.URL)
+		}
+	}
+
+	return nil
+}
@@ -22,36 +22,29 @@ type CrawlOptions struct {
 	RescanInterval string
 	ReaderAPIURL   string
 	Parallelism    int
+	Compression    string
+	Relink         bool
+	Strategy       string
+	QueueSitemap   string
+	IndexHTML      bool
+	Assets         bool
+	Attachments    bool
 	AIEnabled      bool
+	AIProvider     string
 	AIEndpoint     string
 	AIKey          string
 	AIModel        string
 	AIPrompt       string
-}
-
-// findConfigFile looks for config in standard locations
-func findConfigFile(configPath string) string {
-	// Check explicit path first
-	if configPath != "" {
-		if _, err := os.Stat(configPath); err == nil {
-			return configPath
-		}
-	}
-
-	// Check standard locations
-	locations := []string{
-		".stripper.yaml",
-		path.Join(os.Getenv("HOME"), ".stripper.yaml"),
-		"/etc/stripper/config.yaml",
-	}
-
-	for _, loc := range locations {
-		if _, err := os.Stat(loc); err == nil {
-			return loc
-		}
-	}
-
-	return ""
+	AIMaxTokens    int
+	AIMode         string
+	AISchema       string
+	AIRefresh      bool
+	AIBudget       float64
+	AIRPM          int
+	AITPM          int
+	AITags         bool
+	AITaxonomy     []string
+	AITranslate    string
 }
 
 func NewCrawlCmd() *cobra.Command {
@@ -76,10 +69,21 @@ The content will be retrieved using the Reader API and stored locally.`,
 
 	// AI-related flags
 	cmd.Flags().BoolVar(&opts.AIEnabled, "ai", false, "Enable AI summarization")
-	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "https://api.openai.com/v1", "AI API endpoint")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
 	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
-	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "gpt-3.5-turbo", "AI model to use")
+	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "", "AI model to use (default: gpt-3.5-turbo, claude-3-5-haiku-latest or llama3.2, by provider)")
 	cmd.Flags().StringVar(&opts.AIPrompt, "ai-prompt", "", "System prompt for AI summarization")
+	cmd.Flags().IntVar(&opts.AIMaxTokens, "ai-max-tokens", 0, "Maximum estimated input tokens per AI request; larger pages are summarized in chunks")
+	cmd.Flags().StringVar(&opts.AIMode, "ai-mode", "", "AI mode (summarize, extract, translate)")
+	cmd.Flags().StringVar(&opts.AISchema, "ai-schema", "", "JSON Schema file for AI extract mode")
+	cmd.Flags().BoolVar(&opts.AIRefresh, "ai-refresh", false, "Ignore cached AI results and regenerate them")
+	cmd.Flags().IntVar(&opts.AIRPM, "ai-rpm", 0, "Maximum AI requests per minute (default 12)")
+	cmd.Flags().IntVar(&opts.AITPM, "ai-tpm", 0, "Maximum AI tokens per minute (default: no limit)")
+	cmd.Flags().Float64Var(&opts.AIBudget, "ai-budget", 0, "Stop AI processing once the estimated spend of this run reaches this amount")
+	cmd.Flags().BoolVar(&opts.AITags, "ai-tags", false, "Also classify pages with AI tags, content type and language")
+	cmd.Flags().StringSliceVar(&opts.AITaxonomy, "ai-taxonomy", nil, "Tags the AI may assign (default: free-form tags)")
+	cmd.Flags().StringVar(&opts.AITranslate, "ai-translate", "", "Also translate pages into this language (e.g. en)")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", "markdown", "Output format (markdown, text, html)")
 	cmd.Flags().BoolVar(&opts.Force, "force", false, "Force re-crawl of already crawled URLs")
 	cmd.Flags().StringSliceVarP(&opts.Ignore, "ignore", "i", []string{
@@ -90,22 +94,22 @@ The content will be retrieved using the Reader API and stored locally.`,
 	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory for crawled content")
 	cmd.Flags().StringVarP(&opts.RescanInterval, "rescan", "r", "24h", "Rescan interval for previously crawled pages (e.g., 24h, 1h30m, 15m)")
 	cmd.Flags().StringVar(&opts.ReaderAPIURL, "reader-api-url", "https://read.tabnot.space", "Reader API base URL")
+	cmd.Flags().StringVar(&opts.Compression, "compress", "", "Compress stored content (gzip, zstd)")
+	cmd.Flags().BoolVar(&opts.Relink, "relink", false, "Rewrite links between archived pages to local relative paths")
+	cmd.Flags().StringVar(&opts.Strategy, "strategy", "", "Queue strategy (bfs, dfs, priority; default bfs)")
+	cmd.Flags().StringVar(&opts.QueueSitemap, "priority-sitemap", "", "Sitemap URL or file whose priorities the priority strategy uses")
+	cmd.Flags().BoolVar(&opts.IndexHTML, "index-html", false, "Also write an index.html table of contents")
+	cmd.Flags().BoolVar(&opts.Assets, "assets", false, "Download images referenced by pages for offline use")
+	cmd.Flags().BoolVar(&opts.Attachments, "assets-attachments", false, "Also download linked attachments (PDFs, documents) in asset mode")
 
 	return cmd
 }
 
 func runCrawl(opts *CrawlOptions) error {
 	// Load configuration
-	configPath := findConfigFile(opts.ConfigFile)
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := config.Load(opts.ConfigFile)
 	if err != nil {
-		if opts.ConfigFile != "" {
-			// Only return error if user explicitly specified a config file
-			return fmt.Errorf("error loading config file: %w", err)
-		}
-		// Otherwise, use defaults
-		cfg = &config.Config{}
-		config.SetDefaults(cfg)
+		return err
 	}
 
 	// Merge command line flags with config
@@ -117,12 +121,31 @@ func runCrawl(opts *CrawlOptions) error {
 		"rescan":         opts.RescanInterval,
 		"reader-api-url": opts.ReaderAPIURL,
 		"parallelism":    opts.Parallelism,
+		"compression":    opts.Compression,
+		"relink":         opts.Relink,
+		"strategy":       opts.Strategy,
+		"queue-sitemap":  opts.QueueSitemap,
+		"index-html":     opts.IndexHTML,
+		"assets": map[string]interface{}{
+			"enabled":     opts.Assets,
+			"attachments": opts.Attachments,
+		},
 		"ai": map[string]interface{}{
-			"enabled":       opts.AIEnabled,
-			"endpoint":      opts.AIEndpoint,
-			"api_key":       opts.AIKey,
-			"model":         opts.AIModel,
-			"system_prompt": opts.AIPrompt,
+			"enabled":             opts.AIEnabled,
+			"provider":            opts.AIProvider,
+			"endpoint":            opts.AIEndpoint,
+			"api_key":             opts.AIKey,
+			"model":               opts.AIModel,
+			"system_prompt":       opts.AIPrompt,
+			"max_request_tokens":  opts.AIMaxTokens,
+			"mode":                opts.AIMode,
+			"schema_file":         opts.AISchema,
+			"budget":              opts.AIBudget,
+			"requests_per_minute": opts.AIRPM,
+			"tokens_per_minute":   opts.AITPM,
+			"tagging":             opts.AITags,
+			"taxonomy":            opts.AITaxonomy,
+			"translate":           opts.AITranslate,
 		},
 	}
 	config.MergeWithFlags(cfg, flags)
@@ -150,15 +173,30 @@ func runCrawl(opts *CrawlOptions) error {
 		RescanInterval: rescanInterval,
 		ReaderAPIURL:   cfg.Crawler.ReaderAPI.URL,
 		Parallelism:    cfg.Crawler.Parallelism,
+		Compression:    cfg.Crawler.Compression,
+		Relink:         cfg.Crawler.Relink,
 	}
 
+	// Configure the queue order
+	crawlerOpts.Queue = crawler.QueueOptionsFromConfig(cfg)
+
+	// Configure the table of contents
+	crawlerOpts.Index.Enabled = cfg.Crawler.Index.Enabled
+	crawlerOpts.Index.HTML = cfg.Crawler.Index.HTML
+
+	// Configure asset downloads
+	crawlerOpts.Assets.Enabled = cfg.Crawler.Assets.Enabled
+	crawlerOpts.Assets.Attachments = cfg.Crawler.Assets.Attachments
+	crawlerOpts.Assets.AttachmentExts = cfg.Crawler.Assets.AttachmentExts
+
 	// Configure AI settings if enabled
-	crawlerOpts.AI.Enabled = cfg.Crawler.AI.Enabled
-	crawlerOpts.AI.Endpoint = cfg.Crawler.AI.Endpoint
-	crawlerOpts.AI.APIKey = cfg.Crawler.AI.APIKey
-	crawlerOpts.AI.Model = cfg.Crawler.AI.Model
+	crawlerOpts.AI = crawler.AIOptionsFromConfig(cfg)
+	crawlerOpts.AI.Refresh = opts.AIRefresh
 
-	crawlerOpts.AI.SystemPrompt = cfg.Crawler.AI.SystemPrompt
+	// Record the effective configuration with the run
+	if crawlerOpts.Config, err = cfg.Snapshot(); err != nil {
+		return err
+	}
 
 	c, err := crawler.New(crawlerOpts)
 	if err != nil {
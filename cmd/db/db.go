@@ -0,0 +1,141 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+type DBOptions struct {
+	OutputDir string
+	DryRun    bool
+}
+
+func NewDBCmd() *cobra.Command {
+	opts := &DBOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage the crawl database schema",
+		Long: `The crawl database schema is versioned. Every command migrates the
+database to the latest version when it opens it; these commands show the
+version and apply migrations explicitly, for example before backing up an
+archive.`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+
+	version := &cobra.Command{
+		Use:   "version",
+		Short: "Show the schema version and pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersion(opts)
+		},
+	}
+
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(opts)
+		},
+	}
+	migrate.Flags().BoolVar(&opts.DryRun, "dry-run", false, "List pending migrations without applying them")
+
+	cmd.AddCommand(version, migrate)
+
+	return cmd
+}
+
+// openDB opens the crawl database in the output directory without migrating it
+func openDB(outputDir string) (*database.DB, error) {
+	dbPath := path.Join(path.Clean(outputDir), "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+func runVersion(opts *DBOptions) error {
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d (latest: %d)\n", version, database.LatestSchemaVersion())
+
+	applied, err := db.GetAppliedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	for _, m := range applied {
+		fmt.Printf("  %3d  %-40s applied %s\n", m.Version, m.Description, m.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		fmt.Printf("\n%d pending migrations, run stripper db migrate to apply them:\n", len(pending))
+		for _, m := range pending {
+			fmt.Printf("  %3d  %s\n", m.Version, m.Description)
+		}
+	}
+
+	return nil
+}
+
+func runMigrate(opts *DBOptions) error {
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if opts.DryRun {
+		pending, err := db.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("Database schema is up to date")
+			return nil
+		}
+		for _, m := range pending {
+			fmt.Printf("Would apply migration %d: %s\n", m.Version, m.Description)
+		}
+		return nil
+	}
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d: %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("Database schema is up to date")
+		return nil
+	}
+	fmt.Printf("Database schema is at version %d\n", database.LatestSchemaVersion())
+	return nil
+}
@@ -0,0 +1,157 @@
+package digest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type DigestOptions struct {
+	ConfigFile string
+	OutputDir  string
+	Dest       string
+	Format     string
+	Title      string
+	Links      int
+	Since      string
+	AIProvider string
+	AIEndpoint string
+	AIKey      string
+	AIModel    string
+	AIRefresh  bool
+	AIRPM      int
+	AITPM      int
+}
+
+func NewDigestCmd() *cobra.Command {
+	opts := &DigestOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "digest",
+		Short: "Build a site overview from the AI page summaries",
+		Long: `Build a single overview document of a crawled site from the AI summaries of
+its pages. Page summaries are combined into an overview of each URL section,
+with its key links, and the section overviews into an overview of the site.
+
+With --since, the digest also describes the pages added or updated since a
+date (YYYY-MM-DD) or for a duration (e.g. 168h), using the content changes
+recorded between crawls.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDigest(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().StringVar(&opts.Dest, "dest", "", "File to write the digest to (default: digest.md in the output directory)")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "Format of the stored pages (default: crawler.format)")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Site title (default: title of the crawled root page)")
+	cmd.Flags().IntVar(&opts.Links, "links", crawler.DefaultDigestLinks, "Key links listed per section")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Describe pages changed since this date (YYYY-MM-DD) or duration (e.g. 168h)")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "", "AI model to use")
+	cmd.Flags().BoolVar(&opts.AIRefresh, "ai-refresh", false, "Ignore cached AI results and regenerate them")
+	cmd.Flags().IntVar(&opts.AIRPM, "ai-rpm", 0, "Maximum AI requests per minute (default 12)")
+	cmd.Flags().IntVar(&opts.AITPM, "ai-tpm", 0, "Maximum AI tokens per minute (default: no limit)")
+
+	return cmd
+}
+
+func runDigest(opts *DigestOptions) error {
+	since, err := parseSince(opts.Since)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"format": opts.Format,
+		"ai": map[string]interface{}{
+			"provider":            opts.AIProvider,
+			"endpoint":            opts.AIEndpoint,
+			"api_key":             opts.AIKey,
+			"model":               opts.AIModel,
+			"requests_per_minute": opts.AIRPM,
+			"tokens_per_minute":   opts.AITPM,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	dest := opts.Dest
+	if dest == "" {
+		dest = path.Join(outputDir, "digest.md")
+	}
+
+	aiOpts := crawler.AIOptionsFromConfig(cfg)
+	aiOpts.Refresh = opts.AIRefresh
+
+	report, err := crawler.WriteDigest(db, store, crawler.DigestOptions{
+		OutputDir: outputDir,
+		Dest:      dest,
+		Format:    cfg.Crawler.Format,
+		Title:     opts.Title,
+		Links:     opts.Links,
+		Since:     since,
+		AI:        aiOpts,
+	})
+	if err != nil {
+		return fmt.Errorf("digest failed: %w", err)
+	}
+
+	fmt.Printf("Wrote %s covering %d pages in %d sections", dest, report.Pages, report.Sections)
+	if !since.IsZero() {
+		fmt.Printf(", %d changed", report.Changed)
+	}
+	fmt.Println()
+	fmt.Printf("AI usage: %d prompt + %d completion tokens, cost %.4f\n",
+		report.Usage.PromptTokens, report.Usage.CompletionTokens, report.Cost)
+
+	return nil
+}
+
+// parseSince parses --since as a date or as a duration back from now,
+// returning the zero time when it is unset
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q, expected YYYY-MM-DD or a duration such as 168h", value)
+}
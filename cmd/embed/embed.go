@@ -0,0 +1,111 @@
+package embed
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type EmbedOptions struct {
+	ConfigFile     string
+	OutputDir      string
+	Format         string
+	Patterns       []string
+	All            bool
+	ChunkTokens    int
+	AIProvider     string
+	AIEndpoint     string
+	AIKey          string
+	EmbeddingModel string
+}
+
+func NewEmbedCmd() *cobra.Command {
+	opts := &EmbedOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "embed",
+		Short: "Build the semantic search index of an archive",
+		Long: `Cut stored pages into chunks along their headings, generate an embedding
+vector for each chunk and store the vectors in the crawl database for
+stripper search --semantic.
+
+Pages whose content has not changed since they were last indexed with the same
+embedding model are skipped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEmbed(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "Format of the stored pages (default: crawler.format)")
+	cmd.Flags().StringSliceVar(&opts.Patterns, "pattern", nil, "Only index URLs matching these globs (e.g., \"*/docs/*\")")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Re-embed pages that are already indexed")
+	cmd.Flags().IntVar(&opts.ChunkTokens, "chunk-tokens", crawler.DefaultChunkTokens, "Maximum estimated tokens per chunk")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.EmbeddingModel, "embedding-model", "", "Embedding model (default: crawler.ai.embedding_model)")
+
+	return cmd
+}
+
+func runEmbed(opts *EmbedOptions) error {
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"format": opts.Format,
+		"ai": map[string]interface{}{
+			"provider":        opts.AIProvider,
+			"endpoint":        opts.AIEndpoint,
+			"api_key":         opts.AIKey,
+			"embedding_model": opts.EmbeddingModel,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	report, err := crawler.Embed(db, store, crawler.EmbedOptions{
+		Format:      cfg.Crawler.Format,
+		Patterns:    opts.Patterns,
+		All:         opts.All,
+		ChunkTokens: opts.ChunkTokens,
+		AI:          crawler.AIOptionsFromConfig(cfg),
+	})
+	if report != nil {
+		fmt.Printf("Indexed %d pages (%d chunks), %d unchanged, %d tokens\n",
+			report.Pages, report.Chunks, report.Unchanged, report.Usage.Total())
+	}
+	if err != nil {
+		return fmt.Errorf("indexing failed: %w", err)
+	}
+
+	return nil
+}
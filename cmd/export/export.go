@@ -0,0 +1,360 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type ExportOptions struct {
+	OutputDir string
+	Dataset   string
+	As        string
+	Dest      string
+	Sitemap   string
+}
+
+// table is an exported dataset with a fixed column order
+type table struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func NewExportCmd() *cobra.Command {
+	opts := &ExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "export [dataset]",
+		Short: "Export crawl data from the database",
+		Long: `Export data recorded in the crawl database as JSON, JSON Lines or CSV.
+Datasets:
+  links        every queued URL with its status, redirects and AI classification (default)
+  extractions  structured data produced by AI extract mode
+  edges        links and redirects between pages
+  broken       links to pages that failed or answered with an HTTP error
+  orphans      pages no other page links to (sitemap URLs with --sitemap)
+  redirects    redirect chains from the requested to the final URL
+  inbound      the number of pages linking to each page
+
+The edges, broken and redirects datasets can also be exported as a Graphviz
+DOT graph with --as dot.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Dataset = "links"
+			if len(args) > 0 {
+				opts.Dataset = args[0]
+			}
+			return runExport(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory or packed archive of the crawl")
+	cmd.Flags().StringVar(&opts.As, "as", "jsonl", "Export format (json, jsonl, csv, dot)")
+	cmd.Flags().StringVar(&opts.Dest, "dest", "", "File to write to (default: stdout)")
+	cmd.Flags().StringVar(&opts.Sitemap, "sitemap", "", "Sitemap URL or file whose pages are checked for orphans")
+
+	return cmd
+}
+
+func runExport(opts *ExportOptions) error {
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath, cleanup, err := storage.DatabasePath(outputDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var t *table
+	switch opts.Dataset {
+	case "links":
+		t, err = linksTable(db)
+	case "extractions":
+		t, err = extractionsTable(db)
+	case "edges":
+		t, err = edgesTable(db)
+	case "broken":
+		t, err = brokenTable(db)
+	case "orphans":
+		t, err = orphansTable(db, opts.Sitemap)
+	case "redirects":
+		t, err = redirectsTable(db)
+	case "inbound":
+		t, err = inboundTable(db)
+	default:
+		return fmt.Errorf("unknown dataset: %s (use links, extractions, edges, broken, orphans, redirects or inbound)", opts.Dataset)
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.Dest != "" {
+		f, err := os.Create(opts.Dest)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", opts.Dest, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch opts.As {
+	case "json":
+		return writeJSON(w, t)
+	case "jsonl":
+		return writeJSONLines(w, t)
+	case "csv":
+		return writeCSV(w, t)
+	case "dot":
+		return writeDOT(w, t, opts.Dataset)
+	default:
+		return fmt.Errorf("unknown export format: %s (use json, jsonl, csv or dot)", opts.As)
+	}
+}
+
+func linksTable(db *database.DB) (*table, error) {
+	links, err := db.GetLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+
+	classifications, err := db.GetClassifications()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classifications: %w", err)
+	}
+
+	t := &table{columns: []string{"url", "depth", "status", "error", "last_crawled", "ai_status", "ai_error", "tags", "content_type", "language", "final_url", "redirects", "discovered_at", "priority"}}
+	for _, l := range links {
+		c := classifications[l.URL]
+		tags := c.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		redirects := make([]string, 0, len(l.Redirects))
+		for _, r := range l.Redirects {
+			redirects = append(redirects, fmt.Sprintf("%d %s", r.Status, r.URL))
+		}
+		t.rows = append(t.rows, []interface{}{l.URL, l.Depth, l.Status, l.Error, formatTime(l.LastCrawled), l.AIStatus, l.AIError, tags, c.ContentType, c.Language, l.FinalURL, redirects, formatTime(l.DiscoveredAt), l.Priority})
+	}
+	return t, nil
+}
+
+func extractionsTable(db *database.DB) (*table, error) {
+	extractions, err := db.GetExtractions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extractions: %w", err)
+	}
+
+	t := &table{columns: []string{"url", "model", "schema_hash", "extracted_at", "data"}}
+	for _, e := range extractions {
+		t.rows = append(t.rows, []interface{}{e.URL, e.Model, e.SchemaHash, formatTime(e.ExtractedAt), json.RawMessage(e.Data)})
+	}
+	return t, nil
+}
+
+func edgesTable(db *database.DB) (*table, error) {
+	edges, err := db.GetEdges("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edges: %w", err)
+	}
+
+	t := &table{columns: []string{"source", "target", "kind", "anchor", "rel", "discovered_at"}}
+	for _, e := range edges {
+		t.rows = append(t.rows, []interface{}{e.Source, e.Target, e.Kind, e.Anchor, e.Rel, formatTime(e.DiscoveredAt)})
+	}
+	return t, nil
+}
+
+func brokenTable(db *database.DB) (*table, error) {
+	broken, err := db.GetBrokenLinks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read broken links: %w", err)
+	}
+
+	t := &table{columns: []string{"source", "target", "anchor", "error"}}
+	for _, b := range broken {
+		t.rows = append(t.rows, []interface{}{b.Source, b.Target, b.Anchor, b.Error})
+	}
+	return t, nil
+}
+
+func orphansTable(db *database.DB, sitemap string) (*table, error) {
+	var candidates []string
+	if sitemap != "" {
+		var err error
+		if candidates, err = crawler.ReadSitemap(sitemap); err != nil {
+			return nil, err
+		}
+	}
+
+	orphans, err := crawler.Orphans(db, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{columns: []string{"url"}}
+	for _, u := range orphans {
+		t.rows = append(t.rows, []interface{}{u})
+	}
+	return t, nil
+}
+
+func redirectsTable(db *database.DB) (*table, error) {
+	chains, err := crawler.RedirectChains(db)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{columns: []string{"source", "target", "hops", "chain"}}
+	for _, c := range chains {
+		t.rows = append(t.rows, []interface{}{c.Source, c.Target, len(c.Hops) - 1, c.Hops})
+	}
+	return t, nil
+}
+
+func inboundTable(db *database.DB) (*table, error) {
+	counts, err := db.GetInboundCounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count inbound links: %w", err)
+	}
+
+	t := &table{columns: []string{"url", "status", "inbound"}}
+	for _, c := range counts {
+		t.rows = append(t.rows, []interface{}{c.URL, c.Status, c.Inbound})
+	}
+	return t, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// record turns a row into a JSON object keeping the column order
+func (t *table) record(row []interface{}) json.RawMessage {
+	buf := []byte("{")
+	for i, col := range t.columns {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(col)
+		value, err := json.Marshal(row[i])
+		if err != nil {
+			value = []byte("null")
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		buf = append(buf, value...)
+	}
+	return append(buf, '}')
+}
+
+func writeJSON(w io.Writer, t *table) error {
+	records := make([]json.RawMessage, 0, len(t.rows))
+	for _, row := range t.rows {
+		records = append(records, t.record(row))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeJSONLines(w io.Writer, t *table) error {
+	for _, row := range t.rows {
+		if _, err := fmt.Fprintf(w, "%s\n", t.record(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, t *table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.columns); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			switch val := v.(type) {
+			case json.RawMessage:
+				record[i] = string(val)
+			case []string:
+				record[i] = strings.Join(val, ";")
+			default:
+				record[i] = fmt.Sprint(val)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeDOT writes a dataset with source and target columns as a Graphviz
+// directed graph. Redirect chains become one edge per hop.
+func writeDOT(w io.Writer, t *table, name string) error {
+	column := make(map[string]int, len(t.columns))
+	for i, col := range t.columns {
+		column[col] = i
+	}
+	source, hasSource := column["source"]
+	target, hasTarget := column["target"]
+	if !hasSource || !hasTarget {
+		return fmt.Errorf("the %s dataset has no edges to draw, use json, jsonl or csv", name)
+	}
+
+	if _, err := fmt.Fprintf(w, "digraph %s {\n", dotQuote(name)); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		if i, ok := column["chain"]; ok {
+			hops := row[i].([]string)
+			for j := 1; j < len(hops); j++ {
+				if _, err := fmt.Fprintf(w, "  %s -> %s [style=dashed];\n", dotQuote(hops[j-1]), dotQuote(hops[j])); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		attrs := ""
+		if i, ok := column["anchor"]; ok && row[i] != "" {
+			attrs = fmt.Sprintf(" [label=%s]", dotQuote(fmt.Sprint(row[i])))
+		}
+		if i, ok := column["kind"]; ok && row[i] == "redirect" {
+			attrs = " [style=dashed]"
+		}
+		if _, err := fmt.Fprintf(w, "  %s -> %s%s;\n", dotQuote(fmt.Sprint(row[source])), dotQuote(fmt.Sprint(row[target])), attrs); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// dotQuote returns a DOT string literal
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
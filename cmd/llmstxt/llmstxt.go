@@ -0,0 +1,99 @@
+package llmstxt
+
+import (
+	"fmt"
+	"path"
+
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type LLMsTxtOptions struct {
+	OutputDir string
+	DestDir   string
+	Format    string
+	Title     string
+	Include   []string
+	Exclude   []string
+	MaxSize   int
+}
+
+func NewLLMsTxtCmd() *cobra.Command {
+	opts := &LLMsTxtOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "llms-txt",
+		Short: "Generate llms.txt and llms-full.txt from a crawled site",
+		Long: `Generate an llms.txt index and a concatenated llms-full.txt from the pages
+of a crawl. Pages are described with their AI summaries when available.
+Include patterns select pages and define their order; exclude patterns
+drop pages. Patterns are URL globs where * matches any characters.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLLMsTxt(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory or packed archive of the crawl")
+	cmd.Flags().StringVar(&opts.DestDir, "dest", "", "Directory to write llms.txt to (default: the output directory, or the directory of the archive)")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "markdown", "Format of the stored pages (markdown, text, html)")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Site title (default: title of the crawled root page)")
+	cmd.Flags().StringSliceVar(&opts.Include, "include", nil, "URL patterns to include, in output order")
+	cmd.Flags().StringSliceVar(&opts.Exclude, "exclude", nil, "URL patterns to exclude")
+	cmd.Flags().IntVar(&opts.MaxSize, "max-size", 0, "Maximum size of llms-full.txt in bytes (0 for no limit)")
+
+	return cmd
+}
+
+func runLLMsTxt(opts *LLMsTxtOptions) error {
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath, cleanup, err := storage.DatabasePath(outputDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// The files of an archive are written next to it
+	destDir := opts.DestDir
+	if destDir == "" {
+		destDir = outputDir
+		if storage.IsArchive(outputDir) {
+			destDir = path.Dir(outputDir)
+		}
+	}
+
+	report, err := crawler.WriteLLMsTxt(db, store, crawler.LLMsTxtOptions{
+		OutputDir: outputDir,
+		DestDir:   destDir,
+		Format:    opts.Format,
+		Title:     opts.Title,
+		Include:   opts.Include,
+		Exclude:   opts.Exclude,
+		MaxSize:   opts.MaxSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote llms.txt with %d pages and llms-full.txt with %d pages to %s\n", report.Pages, report.FullPages, destDir)
+	if report.Skipped > 0 {
+		fmt.Printf("Left %d pages out of llms-full.txt to stay within %d bytes\n", report.Skipped, opts.MaxSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package pack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type PackOptions struct {
+	OutputDir string
+	Archive   string
+}
+
+func NewPackCmd() *cobra.Command {
+	opts := &PackOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "pack",
+		Short: "Pack an output directory into a single archive file",
+		Long: `Pack a crawl output directory into a single indexed archive file.
+Compressed content is stored under its original name so the archive can be
+read back directly without unpacking it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPack(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory to pack")
+	cmd.Flags().StringVarP(&opts.Archive, "archive", "a", "", "Archive file to write (default: <output>.zip)")
+
+	return cmd
+}
+
+func runPack(opts *PackOptions) error {
+	outputDir := filepath.Clean(opts.OutputDir)
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("output directory not found: %s", outputDir)
+	}
+
+	archive := opts.Archive
+	if archive == "" {
+		archive = outputDir + ".zip"
+	}
+
+	count, err := storage.Pack(outputDir, archive)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Packed %d files from %s into %s\n", count, outputDir, archive)
+	return nil
+}
@@ -0,0 +1,98 @@
+package relink
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type RelinkOptions struct {
+	ConfigFile string
+	OutputDir  string
+	Format     string
+	Ignore     []string
+	Verbose    bool
+}
+
+func NewRelinkCmd() *cobra.Command {
+	opts := &RelinkOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "relink",
+		Short: "Rewrite links between archived pages to local paths",
+		Long: `Rewrite links between archived pages into relative links to the stored
+files so the archive can be browsed offline. External links are left
+untouched and internal links to pages missing from the archive are reported,
+except links to files with an ignored extension, which the crawl skips.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRelink(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "markdown", "Format of the stored pages (markdown, html)")
+	cmd.Flags().StringSliceVarP(&opts.Ignore, "ignore", "i", nil, "File extensions the crawl ignored (default: crawler.ignore_extensions)")
+	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "List every dangling link")
+
+	return cmd
+}
+
+func runRelink(opts *RelinkOptions) error {
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"ignore": opts.Ignore,
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	if storage.IsArchive(outputDir) {
+		return fmt.Errorf("relinking rewrites stored pages, %s is a read-only archive", outputDir)
+	}
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	report, err := crawler.Relink(db, store.(*storage.FileStorage), crawler.RelinkOptions{
+		Format: opts.Format,
+		Ignore: cfg.Crawler.IgnoreExts,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scanned %d pages, rewrote %d links in %d pages\n", report.Pages, report.Rewritten, report.Updated)
+	if len(report.Dangling) > 0 {
+		fmt.Printf("Found %d dangling internal links\n", len(report.Dangling))
+		if opts.Verbose {
+			for _, d := range report.Dangling {
+				fmt.Printf("  %s -> %s\n", d.Page, d.Target)
+			}
+		}
+	}
+
+	return nil
+}
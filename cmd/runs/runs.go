@@ -0,0 +1,195 @@
+package runs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"stripper/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+type RunsOptions struct {
+	OutputDir string
+	Config    bool
+}
+
+func NewRunsCmd() *cobra.Command {
+	opts := &RunsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Show the history of crawl runs",
+		Long: `Every crawl is recorded as a run with its start and end time, seed URLs,
+effective configuration, exit reason and counters.`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List crawl runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(opts)
+		},
+	}
+
+	show := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the details of a crawl run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid run id %q", args[0])
+			}
+			return runShow(opts, id)
+		},
+	}
+	show.Flags().BoolVar(&opts.Config, "config", false, "Print the configuration the run used")
+
+	cmd.AddCommand(list, show)
+
+	return cmd
+}
+
+// openDB opens the crawl database in the output directory
+func openDB(outputDir string) (*database.DB, error) {
+	dbPath := path.Join(path.Clean(outputDir), "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+func runList(opts *RunsOptions) error {
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	runs, err := db.GetRuns()
+	if err != nil {
+		return fmt.Errorf("failed to list runs: %w", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No crawl runs recorded")
+		return nil
+	}
+
+	fmt.Printf("%5s  %-19s %10s %-10s %8s %8s %8s %10s %10s %10s %10s\n",
+		"ID", "STARTED", "DURATION", "EXIT", "FETCHED", "SKIPPED", "FAILED", "REDIRECTED", "SIZE", "TOKENS", "COST")
+	for _, r := range runs {
+		fmt.Printf("%5d  %-19s %10s %-10s %8d %8d %8d %10d %10s %10d %10.4f\n",
+			r.ID, r.StartedAt.Local().Format("2006-01-02 15:04:05"), duration(r), exitReason(r),
+			r.Fetched, r.Skipped, r.Failed, r.Redirected, formatBytes(r.Bytes), r.PromptTokens+r.CompletionTokens, r.Cost)
+	}
+
+	return nil
+}
+
+func runShow(opts *RunsOptions, id int64) error {
+	db, err := openDB(opts.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r, err := db.GetRun(id)
+	if err != nil {
+		return fmt.Errorf("failed to read run: %w", err)
+	}
+	if r == nil {
+		return fmt.Errorf("no run with id %d", id)
+	}
+
+	fmt.Printf("Run %d\n", r.ID)
+	fmt.Printf("  Started:  %s\n", r.StartedAt.Local().Format(time.RFC3339))
+	if !r.FinishedAt.IsZero() {
+		fmt.Printf("  Finished: %s (%s)\n", r.FinishedAt.Local().Format(time.RFC3339), duration(*r))
+	}
+	fmt.Printf("  Exit:     %s\n", exitReason(*r))
+	if r.Error != "" {
+		fmt.Printf("  Error:    %s\n", r.Error)
+	}
+	fmt.Printf("  Seeds:    %s\n", strings.Join(r.Seeds, ", "))
+
+	fmt.Printf("\nPages:\n")
+	fmt.Printf("  • Fetched: %d (%s)\n", r.Fetched, formatBytes(r.Bytes))
+	fmt.Printf("  • Skipped: %d\n", r.Skipped)
+	fmt.Printf("  • Failed: %d\n", r.Failed)
+	fmt.Printf("  • Redirected: %d\n", r.Redirected)
+
+	if r.PromptTokens+r.CompletionTokens > 0 {
+		fmt.Printf("\nAI usage: %d prompt + %d completion tokens, cost %.4f\n", r.PromptTokens, r.CompletionTokens, r.Cost)
+	}
+
+	// Links keep the run that last updated them, so later runs take over the
+	// links they refetched
+	links, err := db.GetRunLinks(r.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list links of run: %w", err)
+	}
+	var failed []database.Link
+	for _, link := range links {
+		if link.Status == "failed" {
+			failed = append(failed, link)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Printf("\nFailed links last updated by this run:\n")
+		for _, link := range failed {
+			fmt.Printf("  • %s: %s\n", link.URL, link.Error)
+		}
+	}
+
+	if opts.Config && r.Config != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(r.Config), "", "  "); err != nil {
+			return fmt.Errorf("failed to format configuration: %w", err)
+		}
+		fmt.Printf("\nConfiguration:\n%s\n", indented.String())
+	}
+
+	return nil
+}
+
+// duration returns how long a run took, or "-" when it did not finish
+func duration(r database.Run) string {
+	if r.FinishedAt.IsZero() {
+		return "-"
+	}
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Second).String()
+}
+
+// exitReason describes how a run ended; runs without an end time are still
+// running or were interrupted
+func exitReason(r database.Run) string {
+	if r.ExitReason == "" {
+		return "unfinished"
+	}
+	return r.ExitReason
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,261 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+// snippetLength caps the chunk text printed for each result
+const snippetLength = 300
+
+// dateLayout is the format of the --since and --until flags
+const dateLayout = "2006-01-02"
+
+type SearchOptions struct {
+	ConfigFile     string
+	OutputDir      string
+	Format         string
+	Query          string
+	Semantic       bool
+	Limit          int
+	Host           string
+	Path           string
+	Since          string
+	Until          string
+	Tag            string
+	ContentType    string
+	Language       string
+	JSON           bool
+	Reindex        bool
+	AIProvider     string
+	AIEndpoint     string
+	AIKey          string
+	EmbeddingModel string
+}
+
+func NewSearchCmd() *cobra.Command {
+	opts := &SearchOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search the archive",
+		Long: `Search the pages of an archive.
+
+By default the full-text index, filled as pages are saved, is searched for
+pages containing every word of the query (a trailing * matches a prefix).
+Results are ranked by relevance, with matches in titles and headings counting
+most, and can be filtered by host, path prefix, crawl date and the tags,
+content type and language assigned by AI tagging. Use --reindex
+to build the index for archives crawled before it existed.
+
+With --semantic the query is embedded and compared against the vectors built
+by stripper embed, returning the closest chunks with their URL and heading.`,
+		Args: cobra.RangeArgs(0, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !opts.Reindex {
+				return fmt.Errorf("a search query is required")
+			}
+			if len(args) == 1 {
+				opts.Query = args[0]
+			}
+			return runSearch(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory or packed archive of the crawl")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "Format of the stored pages, for --reindex (default: crawler.format)")
+	cmd.Flags().BoolVar(&opts.Semantic, "semantic", false, "Search by meaning using the embedding index")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "k", 5, "Number of results")
+	cmd.Flags().StringVar(&opts.Host, "host", "", "Only return pages from this host")
+	cmd.Flags().StringVar(&opts.Path, "path", "", "Only return pages whose URL path starts with this prefix")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only return pages crawled on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only return pages crawled before this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.Tag, "tag", "", "Only return pages the AI tagged with this tag")
+	cmd.Flags().StringVar(&opts.ContentType, "type", "", "Only return pages of this AI content type (reference, tutorial, blog, changelog, marketing, other)")
+	cmd.Flags().StringVar(&opts.Language, "lang", "", "Only return pages in this AI-detected language (ISO 639-1 code)")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "Print results as JSON")
+	cmd.Flags().BoolVar(&opts.Reindex, "reindex", false, "Rebuild the full-text index from the stored pages first")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.EmbeddingModel, "embedding-model", "", "Embedding model (default: crawler.ai.embedding_model)")
+
+	return cmd
+}
+
+// result is a search result as printed with --json
+type result struct {
+	URL       string     `json:"url"`
+	Title     string     `json:"title,omitempty"`
+	Heading   string     `json:"heading,omitempty"`
+	Snippet   string     `json:"snippet"`
+	Score     float64    `json:"score"`
+	CrawledAt *time.Time `json:"crawled_at,omitempty"`
+}
+
+func runSearch(opts *SearchOptions) error {
+	if opts.Semantic && (opts.Host != "" || opts.Path != "" || opts.Since != "" || opts.Until != "" ||
+		opts.Tag != "" || opts.ContentType != "" || opts.Language != "") {
+		return fmt.Errorf("filters only apply to full-text search")
+	}
+	since, err := parseDate("since", opts.Since)
+	if err != nil {
+		return err
+	}
+	until, err := parseDate("until", opts.Until)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"format": opts.Format,
+		"ai": map[string]interface{}{
+			"provider":        opts.AIProvider,
+			"endpoint":        opts.AIEndpoint,
+			"api_key":         opts.AIKey,
+			"embedding_model": opts.EmbeddingModel,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	if opts.Reindex && storage.IsArchive(outputDir) {
+		return fmt.Errorf("--reindex needs an output directory, %s is a read-only archive", outputDir)
+	}
+	dbPath, cleanup, err := storage.DatabasePath(outputDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if opts.Reindex {
+		store, err := storage.Open(outputDir)
+		if err != nil {
+			return err
+		}
+		indexed, err := crawler.IndexArchive(db, store, cfg.Crawler.Format)
+		store.Close()
+		if err != nil {
+			return fmt.Errorf("reindexing failed: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Indexed %d pages\n", indexed)
+		if opts.Query == "" {
+			return nil
+		}
+	}
+
+	var results []result
+	if opts.Semantic {
+		chunks, err := crawler.SemanticSearch(db, crawler.AIOptionsFromConfig(cfg), opts.Query, opts.Limit)
+		if err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			results = append(results, result{URL: c.URL, Heading: c.Heading, Snippet: snippet(c.Content), Score: c.Score})
+		}
+	} else {
+		highlight := [2]string{"**", "**"}
+		if !opts.JSON && isTerminal(os.Stdout) {
+			highlight = [2]string{"\x1b[1m", "\x1b[0m"}
+		}
+		hits, err := db.Search(database.SearchQuery{
+			Query:       opts.Query,
+			Host:        opts.Host,
+			PathPrefix:  opts.Path,
+			Since:       since,
+			Until:       until,
+			Tag:         opts.Tag,
+			ContentType: opts.ContentType,
+			Language:    opts.Language,
+			Limit:       opts.Limit,
+			Highlight:   highlight,
+		})
+		if err != nil {
+			return err
+		}
+		for _, h := range hits {
+			r := result{URL: h.URL, Title: h.Title, Snippet: strings.Join(strings.Fields(h.Snippet), " "), Score: h.Score}
+			if !h.CrawledAt.IsZero() {
+				crawledAt := h.CrawledAt
+				r.CrawledAt = &crawledAt
+			}
+			results = append(results, r)
+		}
+	}
+
+	if opts.JSON {
+		if results == nil {
+			results = []result{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No results")
+		return nil
+	}
+	for i, r := range results {
+		fmt.Printf("%d. %s (score %.3f)\n", i+1, r.URL, r.Score)
+		if r.Title != "" {
+			fmt.Printf("   %s\n", r.Title)
+		}
+		if r.Heading != "" {
+			fmt.Printf("   %s\n", r.Heading)
+		}
+		fmt.Printf("   %s\n\n", r.Snippet)
+	}
+
+	return nil
+}
+
+// parseDate parses the value of a date flag, returning the zero time when
+// the flag is unset
+func parseDate(flag string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s date %q, expected YYYY-MM-DD", flag, value)
+	}
+	return t, nil
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// snippet flattens chunk text to a single line of limited length
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > snippetLength {
+		return string(runes[:snippetLength]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,138 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type ServeOptions struct {
+	OutputDir string
+	Addr      string
+	Format    string
+}
+
+// indexTemplate lists the stored pages
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Source}}</title></head>
+<body>
+<h1>{{.Source}}</h1>
+<p>{{len .Links}} pages</p>
+<ul>
+{{range .Links}}<li><a href="/page?url={{.URL}}">{{.URL}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+// contentTypes are the content types pages are served with, by format.
+// Markdown is served as plain text, which browsers display instead of
+// downloading.
+var contentTypes = map[string]string{
+	"markdown": "text/plain; charset=utf-8",
+	"text":     "text/plain; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+}
+
+func NewServeCmd() *cobra.Command {
+	opts := &ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the pages of a crawl over HTTP",
+		Long: `Serve the stored pages of a crawl output directory or a packed archive over
+HTTP. Archives are read in place, without unpacking them. The index page lists
+every stored page; /page?url=<url> returns the content of one page.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory or packed archive of the crawl")
+	cmd.Flags().StringVar(&opts.Addr, "addr", "localhost:8080", "Address to listen on")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "markdown", "Format of the stored pages (markdown, text, html)")
+
+	return cmd
+}
+
+func runServe(opts *ServeOptions) error {
+	contentType, ok := contentTypes[opts.Format]
+	if !ok {
+		return fmt.Errorf("unknown format: %s (use markdown, text or html)", opts.Format)
+	}
+
+	source := filepath.Clean(opts.OutputDir)
+	dbPath, cleanup, err := storage.DatabasePath(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(source)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		links, err := db.GetLinksByStatus("completed")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		indexTemplate.Execute(w, struct {
+			Source string
+			Links  []database.Link
+		}{source, links})
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		content, err := store.Load(r.URL.Query().Get("url"), opts.Format)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, storage.StripMetadata(content))
+	})
+
+	// Shut down on interrupt so the extracted database of an archive is
+	// removed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: opts.Addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	fmt.Printf("Serving %s on http://%s\n", source, opts.Addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package status
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type StatusOptions struct {
+	OutputDir string
+	Slowest   int
+}
+
+func NewStatusCmd() *cobra.Command {
+	opts := &StatusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of a crawl",
+		Long: `Show link counts for a crawl output directory, the fetch throughput and
+slowest pages of the latest crawl run, and the AI token usage and cost of each
+crawl run.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory or packed archive of the crawl")
+	cmd.Flags().IntVar(&opts.Slowest, "slowest", 5, "Number of slowest pages of the latest run to list")
+
+	return cmd
+}
+
+func runStatus(opts *StatusOptions) error {
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath, cleanup, err := storage.DatabasePath(outputDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	total, pending, completed, failed, redirected, err := db.GetStats()
+	if err != nil {
+		return fmt.Errorf("failed to read link statistics: %w", err)
+	}
+
+	fmt.Printf("Links: %d\n", total)
+	fmt.Printf("  • Completed: %d\n", completed)
+	fmt.Printf("  • Pending: %d\n", pending)
+	fmt.Printf("  • Failed: %d\n", failed)
+	if redirected > 0 {
+		fmt.Printf("  • Redirected: %d\n", redirected)
+	}
+
+	if err := printFetchStats(db, opts.Slowest); err != nil {
+		return err
+	}
+
+	aiStats, err := db.GetAIStats()
+	if err != nil {
+		return fmt.Errorf("failed to read AI statistics: %w", err)
+	}
+	if aiStats["completed"]+aiStats["pending"]+aiStats["failed"] > 0 {
+		fmt.Printf("\nAI processing of stored pages:\n")
+		fmt.Printf("  • Completed: %d\n", aiStats["completed"])
+		fmt.Printf("  • Pending: %d\n", aiStats["pending"])
+		fmt.Printf("  • Failed: %d\n", aiStats["failed"])
+		fmt.Printf("  • Not processed: %d\n", aiStats[""])
+	}
+
+	translations, err := db.GetTranslationStats()
+	if err != nil {
+		return fmt.Errorf("failed to read translation statistics: %w", err)
+	}
+	languages := make([]string, 0, len(translations))
+	for language := range translations {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		stats := translations[language]
+		fmt.Printf("\nTranslation into %s:\n", language)
+		fmt.Printf("  • Completed: %d\n", stats["completed"])
+		fmt.Printf("  • Pending: %d\n", stats["pending"])
+		fmt.Printf("  • Failed: %d\n", stats["failed"])
+	}
+
+	runs, err := db.GetAIUsageByRun()
+	if err != nil {
+		return fmt.Errorf("failed to read AI usage: %w", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("\nNo AI usage recorded")
+		return nil
+	}
+
+	var sum database.RunUsage
+	fmt.Printf("\n%5s  %-22s %8s %12s %12s %12s\n", "ID", "RUN", "PAGES", "PROMPT", "COMPLETION", "COST")
+	for _, r := range runs {
+		id := "-"
+		if r.RunID > 0 {
+			id = strconv.FormatInt(r.RunID, 10)
+		}
+		fmt.Printf("%5s  %-22s %8d %12d %12d %12.4f\n", id, r.Run, r.Pages, r.PromptTokens, r.CompletionTokens, r.Cost)
+		sum.Pages += r.Pages
+		sum.PromptTokens += r.PromptTokens
+		sum.CompletionTokens += r.CompletionTokens
+		sum.Cost += r.Cost
+	}
+	fmt.Printf("%5s  %-22s %8d %12d %12d %12.4f\n", "", "total", sum.Pages, sum.PromptTokens, sum.CompletionTokens, sum.Cost)
+
+	return nil
+}
+
+// printFetchStats prints the fetch throughput and the slowest pages of the
+// latest crawl run
+func printFetchStats(db *database.DB, slowest int) error {
+	runs, err := db.GetRuns()
+	if err != nil {
+		return fmt.Errorf("failed to read runs: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	run := runs[len(runs)-1]
+
+	stats, err := db.GetFetchStats(run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read fetch statistics: %w", err)
+	}
+	if stats.Pages == 0 {
+		return nil
+	}
+
+	end := run.FinishedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	minutes := end.Sub(run.StartedAt).Minutes()
+
+	fmt.Printf("\nFetches in run %d (%s):\n", run.ID, run.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("  • Pages: %d (%d words)\n", stats.Pages, stats.Words)
+	if minutes > 0 {
+		fmt.Printf("  • Throughput: %.1f pages/min\n", float64(stats.Pages)/minutes)
+	}
+	fmt.Printf("  • Average fetch time: %s\n", stats.AvgDuration.Round(time.Millisecond))
+	if stats.AvgReaderLatency > 0 {
+		fmt.Printf("  • Average Reader API latency: %s\n", stats.AvgReaderLatency.Round(time.Millisecond))
+	}
+
+	if slowest <= 0 {
+		return nil
+	}
+	fetches, err := db.GetSlowestFetches(run.ID, slowest)
+	if err != nil {
+		return fmt.Errorf("failed to read slowest pages: %w", err)
+	}
+	fmt.Printf("\nSlowest pages:\n")
+	fmt.Printf("%10s %10s %6s  %s\n", "FETCH", "READER", "STATUS", "URL")
+	for _, f := range fetches {
+		fmt.Printf("%10s %10s %6d  %s\n", f.Duration.Round(time.Millisecond), f.ReaderLatency.Round(time.Millisecond), f.HTTPStatus, f.URL)
+	}
+	return nil
+}
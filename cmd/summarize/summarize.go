@@ -0,0 +1,150 @@
+package summarize
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type SummarizeOptions struct {
+	ConfigFile  string
+	OutputDir   string
+	Format      string
+	Patterns    []string
+	All         bool
+	AIProvider  string
+	AIEndpoint  string
+	AIKey       string
+	AIModel     string
+	AIPrompt    string
+	AIMaxTokens int
+	AIMode      string
+	AISchema    string
+	AIRefresh   bool
+	AIBudget    float64
+	AIRPM       int
+	AITPM       int
+	AITags      bool
+	AITaxonomy  []string
+	AITranslate string
+}
+
+func NewSummarizeCmd() *cobra.Command {
+	opts := &SummarizeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "summarize",
+		Short: "Run AI summarization over an existing archive",
+		Long: `Run AI summarization or extraction over pages already stored in an output
+directory, without recrawling them.
+
+By default only pages without AI output, or whose AI processing is pending or
+failed, are processed, so an interrupted run can be resumed. Use --all to
+reprocess every page, for example after changing the prompt or model.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSummarize(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "Format of the stored pages (default: crawler.format)")
+	cmd.Flags().StringSliceVar(&opts.Patterns, "pattern", nil, "Only process URLs matching these globs (e.g., \"*/docs/*\")")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Reprocess pages that already have AI output")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "", "AI model to use")
+	cmd.Flags().StringVar(&opts.AIPrompt, "ai-prompt", "", "System prompt for AI summarization")
+	cmd.Flags().IntVar(&opts.AIMaxTokens, "ai-max-tokens", 0, "Maximum estimated input tokens per AI request; larger pages are summarized in chunks")
+	cmd.Flags().StringVar(&opts.AIMode, "ai-mode", "", "AI mode (summarize, extract, translate)")
+	cmd.Flags().StringVar(&opts.AISchema, "ai-schema", "", "JSON Schema file for AI extract mode")
+	cmd.Flags().BoolVar(&opts.AIRefresh, "ai-refresh", false, "Ignore cached AI results and regenerate them")
+	cmd.Flags().IntVar(&opts.AIRPM, "ai-rpm", 0, "Maximum AI requests per minute (default 12)")
+	cmd.Flags().IntVar(&opts.AITPM, "ai-tpm", 0, "Maximum AI tokens per minute (default: no limit)")
+	cmd.Flags().Float64Var(&opts.AIBudget, "ai-budget", 0, "Stop AI processing once the estimated spend of this run reaches this amount")
+	cmd.Flags().BoolVar(&opts.AITags, "ai-tags", false, "Also classify pages with AI tags, content type and language")
+	cmd.Flags().StringSliceVar(&opts.AITaxonomy, "ai-taxonomy", nil, "Tags the AI may assign (default: free-form tags)")
+	cmd.Flags().StringVar(&opts.AITranslate, "ai-translate", "", "Also translate pages into this language (e.g. en)")
+
+	return cmd
+}
+
+func runSummarize(opts *SummarizeOptions) error {
+	// Load configuration
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"format": opts.Format,
+		"ai": map[string]interface{}{
+			"provider":            opts.AIProvider,
+			"endpoint":            opts.AIEndpoint,
+			"api_key":             opts.AIKey,
+			"model":               opts.AIModel,
+			"system_prompt":       opts.AIPrompt,
+			"max_request_tokens":  opts.AIMaxTokens,
+			"mode":                opts.AIMode,
+			"schema_file":         opts.AISchema,
+			"budget":              opts.AIBudget,
+			"requests_per_minute": opts.AIRPM,
+			"tokens_per_minute":   opts.AITPM,
+			"tagging":             opts.AITags,
+			"taxonomy":            opts.AITaxonomy,
+			"translate":           opts.AITranslate,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	aiOpts := crawler.AIOptionsFromConfig(cfg)
+	aiOpts.Refresh = opts.AIRefresh
+
+	report, err := crawler.Summarize(db, store, crawler.SummarizeOptions{
+		OutputDir:   outputDir,
+		Format:      cfg.Crawler.Format,
+		Patterns:    opts.Patterns,
+		All:         opts.All,
+		Parallelism: cfg.Crawler.Parallelism,
+		AI:          aiOpts,
+	})
+	if err != nil {
+		return fmt.Errorf("summarization failed: %w", err)
+	}
+
+	fmt.Printf("Processed %d pages (%d failed", report.Completed, report.Failed)
+	if report.Skipped > 0 {
+		fmt.Printf(", %d left pending by the budget", report.Skipped)
+	}
+	fmt.Println(")")
+	fmt.Printf("AI usage: %d prompt + %d completion tokens, cost %.4f\n",
+		report.Usage.PromptTokens, report.Usage.CompletionTokens, report.Cost)
+
+	return nil
+}
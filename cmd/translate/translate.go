@@ -0,0 +1,134 @@
+package translate
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/config"
+	"stripper/internal/crawler"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+type TranslateOptions struct {
+	ConfigFile string
+	OutputDir  string
+	Format     string
+	Language   string
+	Patterns   []string
+	AIProvider string
+	AIEndpoint string
+	AIKey      string
+	AIModel    string
+	AIRefresh  bool
+	AIBudget   float64
+	AIRPM      int
+	AITPM      int
+}
+
+func NewTranslateCmd() *cobra.Command {
+	opts := &TranslateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "translate",
+		Short: "Translate an existing archive with AI",
+		Long: `Translate the pages stored in an output directory into another language.
+
+Translations are written as markdown to translations/<language>/ in the output
+directory, keeping code blocks, link targets and the heading structure of the
+original. Their status is tracked per page in the crawl database; pages whose
+content has not changed since they were last translated are skipped, so an
+interrupted run can simply be started again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTranslate(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ConfigFile, "config", "c", "", "Config file (default is $HOME/.stripper.yaml)")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "output", "Output directory of the crawl")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", "", "Format of the stored pages (default: crawler.format)")
+	cmd.Flags().StringVar(&opts.Language, "to", "", "Target language, e.g. en (default: crawler.ai.translation.language)")
+	cmd.Flags().StringSliceVar(&opts.Patterns, "pattern", nil, "Only translate URLs matching these globs (e.g., \"*/docs/*\")")
+	cmd.Flags().StringVar(&opts.AIProvider, "ai-provider", "", "AI provider (openai, anthropic, ollama)")
+	cmd.Flags().StringVar(&opts.AIEndpoint, "ai-endpoint", "", "AI API endpoint (default: the provider's public API)")
+	cmd.Flags().StringVar(&opts.AIKey, "ai-key", "", "AI API key")
+	cmd.Flags().StringVar(&opts.AIModel, "ai-model", "", "AI model to use")
+	cmd.Flags().BoolVar(&opts.AIRefresh, "ai-refresh", false, "Ignore cached AI results and regenerate them")
+	cmd.Flags().IntVar(&opts.AIRPM, "ai-rpm", 0, "Maximum AI requests per minute (default 12)")
+	cmd.Flags().IntVar(&opts.AITPM, "ai-tpm", 0, "Maximum AI tokens per minute (default: no limit)")
+	cmd.Flags().Float64Var(&opts.AIBudget, "ai-budget", 0, "Stop translating once the estimated spend of this run reaches this amount")
+
+	return cmd
+}
+
+func runTranslate(opts *TranslateOptions) error {
+	cfg, err := config.Load(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	config.MergeWithFlags(cfg, map[string]interface{}{
+		"format": opts.Format,
+		"ai": map[string]interface{}{
+			"provider":            opts.AIProvider,
+			"endpoint":            opts.AIEndpoint,
+			"api_key":             opts.AIKey,
+			"model":               opts.AIModel,
+			"translate":           opts.Language,
+			"budget":              opts.AIBudget,
+			"requests_per_minute": opts.AIRPM,
+			"tokens_per_minute":   opts.AITPM,
+		},
+	})
+
+	outputDir := path.Clean(opts.OutputDir)
+	dbPath := path.Join(outputDir, "crawler.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("no crawl database found in %s", outputDir)
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := storage.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	aiOpts := crawler.AIOptionsFromConfig(cfg)
+	aiOpts.Mode = "translate"
+	aiOpts.Tagging = false
+	aiOpts.Refresh = opts.AIRefresh
+
+	// Every page is submitted; those already translated are skipped by
+	// content hash without calling the model
+	report, err := crawler.Summarize(db, store, crawler.SummarizeOptions{
+		OutputDir:   outputDir,
+		Format:      cfg.Crawler.Format,
+		Patterns:    opts.Patterns,
+		All:         true,
+		Parallelism: cfg.Crawler.Parallelism,
+		AI:          aiOpts,
+	})
+	if err != nil {
+		return fmt.Errorf("translation failed: %w", err)
+	}
+
+	fmt.Printf("%d pages translated into %s or already up to date (%d failed", report.Completed, aiOpts.TranslateTo, report.Failed)
+	if report.Skipped > 0 {
+		fmt.Printf(", %d left by the budget", report.Skipped)
+	}
+	fmt.Println(")")
+	fmt.Printf("AI usage: %d prompt + %d completion tokens, cost %.4f\n",
+		report.Usage.PromptTokens, report.Usage.CompletionTokens, report.Cost)
+
+	return nil
+}
@@ -1,25 +1,39 @@
 package ai
 
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-)
-
 // Client handles interactions with the AI API
 type Client struct {
-	endpoint string
-	apiKey   string
-	model    string
-	client   *http.Client
+	provider         Provider
+	model            string
+	maxRequestTokens int
+	cache            Cache
+	refresh          bool
+	limiter          *Limiter
+	embeddingModel   string
 }
 
 // Options configures the AI client
 type Options struct {
-	Endpoint string
+	Provider string // "openai" (default), "anthropic" or "ollama"
+	Endpoint string // defaults to the provider's public API
 	APIKey   string
-	Model    string
+	Model    string // defaults to the provider's DefaultModel
+
+	// EmbeddingModel is used by Embed, defaulting to DefaultEmbeddingModel
+	EmbeddingModel string
+
+	// MaxRequestTokens caps the estimated input tokens of a single request.
+	// Larger pages are summarized in chunks and the results combined.
+	MaxRequestTokens int
+
+	// Cache, when set, is consulted before each request. Refresh skips the
+	// lookup but still stores new results.
+	Cache   Cache
+	Refresh bool
+
+	// RequestsPerMinute and TokensPerMinute are shared by every request made
+	// through the client; 0 disables the limit
+	RequestsPerMinute int
+	TokensPerMinute   int
 }
 
 // Message represents a chat message
@@ -28,82 +42,60 @@ type Message struct {
 	Content string `json:"content"`
 }
 
-// ChatRequest represents a request to the chat completion API
-type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-}
-
-// ChatResponse represents a response from the chat completion API
-type ChatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
-
 // New creates a new AI client
-func New(opts Options) *Client {
-	return &Client{
-		endpoint: opts.Endpoint,
-		apiKey:   opts.APIKey,
-		model:    opts.Model,
-		client:   &http.Client{},
-	}
-}
-
-// Summarize generates a summary of the provided content using the AI model
-func (c *Client) Summarize(content string, systemPrompt string) (string, error) {
-	url := fmt.Sprintf("%s/chat/completions", c.endpoint)
-
-	messages := []Message{
-		{
-			Role:    "system",
-			Content: systemPrompt,
-		},
-		{
-			Role:    "user",
-			Content: content,
-		},
-	}
-
-	reqBody := ChatRequest{
-		Model:    c.model,
-		Messages: messages,
+func New(opts Options) (*Client, error) {
+	if opts.Model == "" {
+		opts.Model = DefaultModel(opts.Provider)
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
+	provider, err := NewProvider(opts)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+	maxRequestTokens := opts.MaxRequestTokens
+	if maxRequestTokens <= 0 {
+		maxRequestTokens = DefaultMaxRequestTokens
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("error making request: %w", err)
+	embeddingModel := opts.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = DefaultEmbeddingModel(opts.Provider)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
+	return &Client{
+		provider:         provider,
+		model:            opts.Model,
+		maxRequestTokens: maxRequestTokens,
+		cache:            opts.Cache,
+		refresh:          opts.Refresh,
+		limiter:          NewLimiter(opts.RequestsPerMinute, opts.TokensPerMinute),
+		embeddingModel:   embeddingModel,
+	}, nil
+}
 
-	var chatResp ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("error decoding response: %w", err)
+// Provider returns the provider used by the client
+func (c *Client) Provider() Provider {
+	return c.provider
+}
+
+// send passes a request to the provider once the rate limiter allows it.
+// The retry hint of a retryable error pauses every request of the client.
+func (c *Client) send(req Request) (*Response, error) {
+	estimated := EstimateTokens(req.System)
+	for _, m := range req.Messages {
+		estimated += EstimateTokens(m.Content)
 	}
+	c.limiter.Wait(estimated)
 
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no summary generated")
+	resp, err := c.provider.Complete(req)
+	if err != nil {
+		if wait := RetryAfter(err); wait > 0 {
+			c.limiter.Pause(wait)
+		}
+		return nil, err
 	}
 
-	return chatResp.Choices[0].Message.Content, nil
+	c.limiter.Record(estimated, resp.Usage.Total())
+	return resp, nil
 }
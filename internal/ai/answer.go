@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// answerPrompt is the system prompt used to answer questions from sources
+const answerPrompt = `You answer questions about a website using only the numbered sources provided by the user. Cite the sources you rely on inline as [1], [2], etc. If the sources do not contain the answer, say so instead of guessing. Answer concisely in markdown.`
+
+// Source is a passage of a page given to the model as context for an answer
+type Source struct {
+	URL     string
+	Heading string
+	Content string
+}
+
+// Answer asks the model a question about the given sources. Sources are
+// numbered in order so the answer can cite them as [n]; sources that do not
+// fit in the request budget are left out. It returns the answer and the
+// number of sources that were sent.
+func (c *Client) Answer(question string, sources []Source) (string, int, Usage, error) {
+	budget := c.chunkBudget(answerPrompt) - EstimateTokens(question)
+
+	var b strings.Builder
+	used := 0
+	for i, source := range sources {
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "[%d] %s\n", i+1, source.URL)
+		if source.Heading != "" {
+			fmt.Fprintf(&entry, "Section: %s\n", source.Heading)
+		}
+		fmt.Fprintf(&entry, "\n%s\n\n", strings.TrimSpace(source.Content))
+
+		tokens := EstimateTokens(entry.String())
+		if used > 0 && tokens > budget {
+			break
+		}
+		b.WriteString(entry.String())
+		budget -= tokens
+		used++
+	}
+	if used == 0 {
+		return "", 0, Usage{}, fmt.Errorf("no sources to answer from")
+	}
+
+	content := fmt.Sprintf("Sources:\n\n%sQuestion: %s", b.String(), question)
+
+	var usage Usage
+	answer, err := c.complete(answerPrompt, content, &usage)
+	if err != nil {
+		return "", used, usage, err
+	}
+	return answer, used, usage, nil
+}
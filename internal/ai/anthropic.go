@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+
+	// anthropicMaxTokens is used when a request does not set MaxTokens,
+	// the Messages API requires an explicit limit
+	anthropicMaxTokens = 4096
+)
+
+// AnthropicProvider talks to the Anthropic Messages API
+type AnthropicProvider struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// anthropicRequest is the body of a Messages API request
+type anthropicRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []Message `json:"messages"`
+}
+
+// anthropicResponse is the body of a Messages API response
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicError is the error body returned by the Messages API
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Name returns the provider identifier
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// Complete sends a Messages API request
+func (p *AnthropicProvider) Complete(req Request) (*Response, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = anthropicMaxTokens
+	}
+
+	headers := map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	resp, body, err := postJSON(p.client, p.endpoint+"/messages", headers, anthropicRequest{
+		Model:     p.model,
+		MaxTokens: maxTokens,
+		System:    req.System,
+		Messages:  req.Messages,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.classify(resp, body)
+	}
+
+	var msgResp anthropicResponse
+	if err := json.Unmarshal(body, &msgResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return &Response{
+		Content: text.String(),
+		Usage: Usage{
+			PromptTokens:     msgResp.Usage.InputTokens,
+			CompletionTokens: msgResp.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// classify maps an Anthropic error response to an APIError
+func (p *AnthropicProvider) classify(resp *http.Response, body []byte) *APIError {
+	apiErr := newAPIError(p.Name(), resp)
+
+	var errBody anthropicError
+	if json.Unmarshal(body, &errBody) == nil {
+		apiErr.Message = errBody.Error.Message
+		switch errBody.Error.Type {
+		case "rate_limit_error":
+			apiErr.Kind = ErrorKindRateLimit
+		case "overloaded_error":
+			apiErr.Kind = ErrorKindOverloaded
+		case "authentication_error", "permission_error":
+			apiErr.Kind = ErrorKindAuth
+		case "invalid_request_error":
+			apiErr.Kind = ErrorKindInvalidRequest
+			if strings.Contains(errBody.Error.Message, "prompt is too long") {
+				apiErr.Kind = ErrorKindContextLength
+			}
+		case "api_error":
+			apiErr.Kind = ErrorKindServer
+		}
+	}
+
+	return apiErr
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAnthropicComplete(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{
+		"content": [{"type": "text", "text": "A "}, {"type": "tool_use"}, {"type": "text", "text": "summary."}],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 42, "output_tokens": 7}
+	}`)
+	p := newTestProvider(t, "anthropic", "claude-3-5-haiku-latest", s)
+
+	resp, err := p.Complete(Request{
+		System:   "Be brief.",
+		Messages: []Message{{Role: "user", Content: "Page text"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.path != "/messages" {
+		t.Errorf("path = %s", s.path)
+	}
+	if got := s.header.Get("x-api-key"); got != "test-key" {
+		t.Errorf("x-api-key = %q", got)
+	}
+	if got := s.header.Get("anthropic-version"); got != anthropicVersion {
+		t.Errorf("anthropic-version = %q", got)
+	}
+	// The system prompt is a top-level field, and max_tokens is required
+	want := jsonValue(t, `{
+		"model": "claude-3-5-haiku-latest",
+		"max_tokens": `+strconv.Itoa(anthropicMaxTokens)+`,
+		"system": "Be brief.",
+		"messages": [{"role": "user", "content": "Page text"}]
+	}`)
+	if !reflect.DeepEqual(interface{}(s.body), want) {
+		t.Errorf("request body = %v, want %v", s.body, want)
+	}
+
+	if resp.Content != "A summary." || resp.Usage != (Usage{PromptTokens: 42, CompletionTokens: 7}) {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestAnthropicMaxTokens(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{"content": [{"type": "text", "text": "ok"}]}`)
+	if _, err := newTestProvider(t, "anthropic", "claude-3-5-haiku-latest", s).Complete(Request{
+		Messages:  []Message{{Role: "user", Content: "Page text"}},
+		MaxTokens: 300,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.body["max_tokens"]; got != float64(300) {
+		t.Errorf("max_tokens = %v, want 300", got)
+	}
+	if _, ok := s.body["system"]; ok {
+		t.Error("empty system prompt was sent")
+	}
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	now := time.Now()
+	testErrors(t, "anthropic", []errorCase{
+		{
+			name:   "rate limit",
+			status: http.StatusTooManyRequests,
+			header: http.Header{
+				"Retry-After":                      {"12"},
+				"Anthropic-Ratelimit-Tokens-Reset": {now.Add(time.Minute).Format(time.RFC3339)},
+			},
+			body:       `{"type": "error", "error": {"type": "rate_limit_error", "message": "Number of request tokens has exceeded your rate limit"}}`,
+			kind:       ErrorKindRateLimit,
+			retryAfter: 12 * time.Second,
+			message:    "Number of request tokens has exceeded your rate limit",
+		},
+		{
+			name:    "overloaded",
+			status:  529,
+			body:    `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			kind:    ErrorKindOverloaded,
+			message: "Overloaded",
+		},
+		{
+			name:   "prompt too long",
+			status: http.StatusBadRequest,
+			header: http.Header{
+				"Anthropic-Ratelimit-Requests-Reset": {now.Add(time.Minute).Format(time.RFC3339)},
+			},
+			body:    `{"type": "error", "error": {"type": "invalid_request_error", "message": "prompt is too long: 250000 tokens > 200000 maximum"}}`,
+			kind:    ErrorKindContextLength,
+			message: "prompt is too long: 250000 tokens > 200000 maximum",
+		},
+		{
+			name:    "permission",
+			status:  http.StatusForbidden,
+			body:    `{"type": "error", "error": {"type": "permission_error", "message": "Your API key does not have permission"}}`,
+			kind:    ErrorKindAuth,
+			message: "Your API key does not have permission",
+		},
+		{
+			name:    "api error",
+			status:  http.StatusInternalServerError,
+			body:    `{"type": "error", "error": {"type": "api_error", "message": "Internal server error"}}`,
+			kind:    ErrorKindServer,
+			message: "Internal server error",
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// CacheKey identifies an AI result by everything that influences it
+type CacheKey struct {
+	ContentHash string
+	Model       string
+	PromptHash  string
+	Provider    string
+}
+
+// Cache stores AI results between runs so unchanged pages are not sent to
+// the model again
+type Cache interface {
+	Get(key CacheKey) (string, bool, error)
+	Put(key CacheKey, result string) error
+}
+
+// HashText returns the hex SHA-256 of text, used for cache keys
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheKey builds the cache key for a request made by the client
+func (c *Client) cacheKey(content string, prompt string) CacheKey {
+	return CacheKey{
+		ContentHash: HashText(content),
+		Model:       c.model,
+		PromptHash:  HashText(prompt),
+		Provider:    c.provider.Name(),
+	}
+}
+
+// cached returns the cached result for key, unless the client is set to
+// refresh results
+func (c *Client) cached(key CacheKey) (string, bool) {
+	if c.cache == nil || c.refresh {
+		return "", false
+	}
+	result, ok, err := c.cache.Get(key)
+	if err != nil || !ok {
+		return "", false
+	}
+	return result, true
+}
+
+// store saves a result in the cache, ignoring cache errors since the result
+// itself is still usable
+func (c *Client) store(key CacheKey, result string) {
+	if c.cache != nil {
+		c.cache.Put(key, result)
+	}
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxFreeTags limits the tags of a page when no taxonomy is configured
+const maxFreeTags = 5
+
+// ContentTypes are the kinds of page a classification can report
+var ContentTypes = []string{"reference", "tutorial", "blog", "changelog", "marketing", "other"}
+
+// Classification labels a page with topic tags, a content type and the
+// language it is written in
+type Classification struct {
+	Tags        []string `json:"tags"`
+	ContentType string   `json:"content_type"`
+	Language    string   `json:"language"` // ISO 639-1 code
+}
+
+// Classify assigns tags, a content type and a language to content. With a
+// taxonomy only its labels are used as tags; otherwise up to a few free-form
+// tags are chosen. Classification only needs the start of a page, so content
+// larger than a single request is truncated to the first chunk. The returned
+// usage is zero for cached results.
+func (c *Client) Classify(content string, taxonomy []string) (*Classification, Usage, error) {
+	systemPrompt := classifyPrompt(taxonomy)
+
+	key := c.cacheKey(content, systemPrompt)
+	if result, ok := c.cached(key); ok {
+		if cls, err := parseClassification(result, taxonomy); err == nil {
+			return cls, Usage{}, nil
+		}
+	}
+
+	if chunks := ChunkMarkdown(content, c.chunkBudget(systemPrompt)); len(chunks) > 1 {
+		content = chunks[0]
+	}
+
+	var usage Usage
+	text, err := c.complete(systemPrompt, content, &usage)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	cls, err := parseClassification(text, taxonomy)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	data, _ := json.Marshal(cls)
+	c.store(key, string(data))
+	return cls, usage, nil
+}
+
+// classifyPrompt builds the system prompt for the taxonomy
+func classifyPrompt(taxonomy []string) string {
+	var b strings.Builder
+	b.WriteString("You classify web pages. Read the document and respond with a single JSON object, and nothing else, with these fields:\n")
+	if len(taxonomy) > 0 {
+		fmt.Fprintf(&b, "- \"tags\": the labels from this list that describe the page's topics, and no others: %s\n",
+			strings.Join(taxonomy, ", "))
+	} else {
+		fmt.Fprintf(&b, "- \"tags\": up to %d short lowercase topic tags\n", maxFreeTags)
+	}
+	fmt.Fprintf(&b, "- \"content_type\": one of %s\n", strings.Join(ContentTypes, ", "))
+	b.WriteString("- \"language\": the ISO 639-1 code of the language the page is written in")
+	return b.String()
+}
+
+// parseClassification decodes a model response and normalizes it: tags are
+// matched to the taxonomy (or lowercased and capped when there is none),
+// unknown content types become "other" and the language is lowercased
+func parseClassification(text string, taxonomy []string) (*Classification, error) {
+	var cls Classification
+	if err := json.Unmarshal([]byte(stripCodeFence(text)), &cls); err != nil {
+		return nil, fmt.Errorf("classification is not valid JSON: %w", err)
+	}
+
+	labels := make(map[string]string, len(taxonomy))
+	for _, label := range taxonomy {
+		labels[strings.ToLower(label)] = label
+	}
+
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(cls.Tags))
+	for _, tag := range cls.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if len(taxonomy) > 0 {
+			label, ok := labels[tag]
+			if !ok {
+				continue
+			}
+			tag = label
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(taxonomy) == 0 && len(tags) > maxFreeTags {
+		tags = tags[:maxFreeTags]
+	}
+	cls.Tags = tags
+
+	cls.ContentType = strings.ToLower(strings.TrimSpace(cls.ContentType))
+	known := false
+	for _, t := range ContentTypes {
+		if cls.ContentType == t {
+			known = true
+			break
+		}
+	}
+	if !known {
+		cls.ContentType = "other"
+	}
+
+	cls.Language = strings.ToLower(strings.TrimSpace(cls.Language))
+	return &cls, nil
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		taxonomy []string
+		want     *Classification
+		wantErr  bool
+	}{
+		{
+			name: "free tags are lowercased and deduplicated",
+			text: `{"tags": ["Go", " go ", "CLI", ""], "content_type": "Tutorial", "language": "EN"}`,
+			want: &Classification{Tags: []string{"go", "cli"}, ContentType: "tutorial", Language: "en"},
+		},
+		{
+			name: "free tags are capped",
+			text: `{"tags": ["a", "b", "c", "d", "e", "f", "g"], "content_type": "blog", "language": "de"}`,
+			want: &Classification{Tags: []string{"a", "b", "c", "d", "e"}, ContentType: "blog", Language: "de"},
+		},
+		{
+			name:     "taxonomy labels keep their spelling and others are dropped",
+			text:     `{"tags": ["api reference", "Security", "cooking"], "content_type": "reference", "language": "en"}`,
+			taxonomy: []string{"API Reference", "Security", "Billing"},
+			want:     &Classification{Tags: []string{"API Reference", "Security"}, ContentType: "reference", Language: "en"},
+		},
+		{
+			name: "unknown content type becomes other",
+			text: `{"tags": [], "content_type": "recipe", "language": "fr"}`,
+			want: &Classification{Tags: []string{}, ContentType: "other", Language: "fr"},
+		},
+		{
+			name: "code fence",
+			text: "```json\n{\"tags\": [\"go\"], \"content_type\": \"changelog\", \"language\": \"en\"}\n```",
+			want: &Classification{Tags: []string{"go"}, ContentType: "changelog", Language: "en"},
+		},
+		{
+			name:    "not JSON",
+			text:    "This page is a tutorial about Go.",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := parseClassification(tt.text, tt.taxonomy)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxEmbedBatch limits how many texts are embedded in a single request
+const maxEmbedBatch = 64
+
+// Embedder is implemented by providers that can turn text into vectors
+type Embedder interface {
+	// Embed returns one vector per input text, in order
+	Embed(model string, texts []string) ([][]float32, Usage, error)
+}
+
+// defaultEmbeddingModels are the embedding models used when none is
+// configured, by provider
+var defaultEmbeddingModels = map[string]string{
+	"openai": "text-embedding-3-small",
+	"ollama": "nomic-embed-text",
+}
+
+// DefaultEmbeddingModel returns the embedding model used with a provider when
+// none is configured, or "" for a provider without embeddings
+func DefaultEmbeddingModel(provider string) string {
+	name := strings.ToLower(provider)
+	if name == "" {
+		name = "openai"
+	}
+	return defaultEmbeddingModels[name]
+}
+
+// Embed returns embedding vectors for texts using the embedding model.
+// Large inputs are sent in batches, each going through the rate limiter.
+func (c *Client) Embed(texts []string) ([][]float32, Usage, error) {
+	embedder, ok := c.provider.(Embedder)
+	if !ok {
+		return nil, Usage{}, fmt.Errorf("the %s provider does not support embeddings", c.provider.Name())
+	}
+
+	var usage Usage
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbedBatch {
+		end := min(start+maxEmbedBatch, len(texts))
+		batch := texts[start:end]
+
+		estimated := 0
+		for _, text := range batch {
+			estimated += EstimateTokens(text)
+		}
+		c.limiter.Wait(estimated)
+
+		batchVectors, batchUsage, err := embedder.Embed(c.embeddingModel, batch)
+		if err != nil {
+			if wait := RetryAfter(err); wait > 0 {
+				c.limiter.Pause(wait)
+			}
+			return nil, usage, err
+		}
+		if len(batchVectors) != len(batch) {
+			return nil, usage, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(batchVectors))
+		}
+		for i, vector := range batchVectors {
+			if len(vector) == 0 {
+				return nil, usage, fmt.Errorf("missing embedding for input %d", start+i)
+			}
+		}
+		c.limiter.Record(estimated, batchUsage.Total())
+
+		usage.Add(batchUsage)
+		vectors = append(vectors, batchVectors...)
+	}
+
+	return vectors, usage, nil
+}
+
+// EmbeddingModel returns the model used for embeddings
+func (c *Client) EmbeddingModel() string {
+	return c.embeddingModel
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, 0 when their
+// lengths differ or either is zero
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
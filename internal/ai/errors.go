@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorKind classifies API errors independently of the provider
+type ErrorKind string
+
+const (
+	ErrorKindRateLimit      ErrorKind = "rate_limit"
+	ErrorKindQuota          ErrorKind = "quota"
+	ErrorKindAuth           ErrorKind = "auth"
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+	ErrorKindContextLength  ErrorKind = "context_length"
+	ErrorKindOverloaded     ErrorKind = "overloaded"
+	ErrorKindServer         ErrorKind = "server"
+	ErrorKindUnknown        ErrorKind = "unknown"
+)
+
+// APIError is returned when a provider rejects a request
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Kind       ErrorKind
+	Message    string
+
+	// RetryAfter is how long the provider asked clients to wait before
+	// sending another request, zero when it gave no hint
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s API request failed with status %d (%s)", e.Provider, e.StatusCode, e.Kind)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %v)", e.RetryAfter)
+	}
+	return msg
+}
+
+// Retryable reports whether the request may succeed if sent again later
+func (e *APIError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimit, ErrorKindOverloaded, ErrorKindServer:
+		return true
+	}
+	return false
+}
+
+// IsRateLimit reports whether err is a rate limit error from any provider
+func IsRateLimit(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Kind == ErrorKindRateLimit
+}
+
+// IsRetryable reports whether err is an API error worth retrying
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// RetryAfter returns the wait requested by the provider with a retryable
+// err, or zero
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Retryable() {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// newAPIError classifies an error response by status code. Retry hints are
+// only read from rate limit and overload responses: providers send their
+// rate limit reset headers with every response, including ones that have
+// nothing to do with rate limits.
+func newAPIError(provider string, resp *http.Response) *APIError {
+	apiErr := &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Kind:       kindFromStatus(resp.StatusCode),
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, 529:
+		apiErr.RetryAfter = retryHint(resp.Header, time.Now())
+	}
+	return apiErr
+}
+
+// retryHint reads how long to wait before retrying from response headers:
+// Retry-After (seconds or HTTP date) or retry-after-ms when present, and
+// otherwise the time until the exhausted limit resets from OpenAI style
+// x-ratelimit-reset-* durations and Anthropic style
+// anthropic-ratelimit-*-reset timestamps. The longest reset wins since every
+// exhausted limit must recover.
+func retryHint(h http.Header, now time.Time) time.Duration {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+
+	var wait time.Duration
+	for _, name := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(h.Get(name)); err == nil && d > wait {
+			wait = d
+		}
+	}
+	for _, name := range []string{
+		"anthropic-ratelimit-requests-reset",
+		"anthropic-ratelimit-tokens-reset",
+		"anthropic-ratelimit-input-tokens-reset",
+		"anthropic-ratelimit-output-tokens-reset",
+	} {
+		if at, err := time.Parse(time.RFC3339, h.Get(name)); err == nil && at.Sub(now) > wait {
+			wait = at.Sub(now)
+		}
+	}
+	return wait
+}
+
+// kindFromStatus classifies an error by HTTP status code alone
+func kindFromStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimit
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindAuth
+	case status == http.StatusBadRequest || status == http.StatusNotFound || status == http.StatusUnprocessableEntity:
+		return ErrorKindInvalidRequest
+	case status == http.StatusServiceUnavailable || status == 529:
+		return ErrorKindOverloaded
+	case status >= 500:
+		return ErrorKindServer
+	}
+	return ErrorKindUnknown
+}
@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// errorProvider fails every request with the error response it was given
+type errorProvider struct {
+	status int
+	header http.Header
+}
+
+func (p *errorProvider) Name() string { return "test" }
+
+func (p *errorProvider) Complete(req Request) (*Response, error) {
+	rec := httptest.NewRecorder()
+	for name, values := range p.header {
+		rec.Header()[name] = values
+	}
+	rec.WriteHeader(p.status)
+	return nil, newAPIError(p.Name(), rec.Result())
+}
+
+// resetHeaders are the rate limit headers OpenAI and Anthropic send with
+// every response
+func resetHeaders(now time.Time) http.Header {
+	h := http.Header{}
+	h.Set("x-ratelimit-reset-requests", "1m30s")
+	h.Set("x-ratelimit-reset-tokens", "45s")
+	h.Set("anthropic-ratelimit-tokens-reset", now.Add(2*time.Minute).Format(time.RFC3339))
+	return h
+}
+
+func TestRetryHints(t *testing.T) {
+	now := time.Now()
+	withRetryAfter := resetHeaders(now)
+	withRetryAfter.Set("Retry-After", "7")
+	withRetryAfterMs := resetHeaders(now)
+	withRetryAfterMs.Set("retry-after-ms", "1500")
+
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"bad request ignores reset headers", http.StatusBadRequest, resetHeaders(now), 0, 0},
+		{"auth error ignores reset headers", http.StatusUnauthorized, resetHeaders(now), 0, 0},
+		{"server error ignores reset headers", http.StatusInternalServerError, resetHeaders(now), 0, 0},
+		{"Retry-After wins over reset headers", http.StatusTooManyRequests, withRetryAfter, 7 * time.Second, 7 * time.Second},
+		{"retry-after-ms wins over reset headers", http.StatusTooManyRequests, withRetryAfterMs, 1500 * time.Millisecond, 1500 * time.Millisecond},
+		{"reset headers without Retry-After", http.StatusTooManyRequests, resetHeaders(now), 110 * time.Second, 2 * time.Minute},
+		{"overloaded", http.StatusServiceUnavailable, withRetryAfter, 7 * time.Second, 7 * time.Second},
+		{"no headers", http.StatusTooManyRequests, http.Header{}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		_, err := (&errorProvider{status: tt.status, header: tt.header}).Complete(Request{})
+		if got := RetryAfter(err); got < tt.min || got > tt.max {
+			t.Errorf("%s: RetryAfter = %v, want between %v and %v", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestNonRetryableErrorDoesNotPauseLimiter(t *testing.T) {
+	client := &Client{
+		provider: &errorProvider{status: http.StatusBadRequest, header: resetHeaders(time.Now())},
+		limiter:  NewLimiter(0, 0),
+	}
+
+	if _, err := client.send(Request{Messages: []Message{{Role: "user", Content: "hi"}}}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !client.limiter.pausedUntil.IsZero() {
+		t.Errorf("limiter paused until %v after a 400", client.limiter.pausedUntil)
+	}
+
+	client.provider = &errorProvider{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"30"}}}
+	client.send(Request{Messages: []Message{{Role: "user", Content: "hi"}}})
+	if time.Until(client.limiter.pausedUntil) < 25*time.Second {
+		t.Errorf("limiter not paused after a 429 with Retry-After")
+	}
+}
@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxExtractAttempts limits how often an invalid extraction is retried
+const maxExtractAttempts = 3
+
+// DefaultExtractPrompt is used when no extraction instructions are configured
+const DefaultExtractPrompt = `You extract structured data from web-scraped markdown or text documents. Read the document and fill in the requested fields using only information found in it. Use null or empty values for information that is not present.`
+
+// Extract pulls structured data out of content as JSON conforming to schema.
+// The response is validated against the schema and the model is asked to
+// correct itself when it returns invalid output. Content larger than a single
+// request is extracted chunk by chunk and the results merged. The returned
+// usage covers every attempt and is zero for cached results.
+func (c *Client) Extract(content string, schema *Schema, instructions string) (string, Usage, error) {
+	if instructions == "" {
+		instructions = DefaultExtractPrompt
+	}
+	systemPrompt := fmt.Sprintf("%s\n\nRespond with a single JSON document, and nothing else, that conforms to this JSON Schema:\n%s", instructions, schema.Raw())
+
+	key := c.cacheKey(content, systemPrompt)
+	if result, ok := c.cached(key); ok {
+		return result, Usage{}, nil
+	}
+
+	chunks := ChunkMarkdown(content, c.chunkBudget(systemPrompt))
+	if len(chunks) <= 1 {
+		result, usage, err := c.extractChunk(content, systemPrompt, schema)
+		if err != nil {
+			return "", usage, err
+		}
+		c.store(key, result)
+		return result, usage, nil
+	}
+
+	var usage Usage
+	var merged interface{}
+	for i, chunk := range chunks {
+		prompt := fmt.Sprintf("%s\n\nThe document is split into %d parts and this is part %d. Fill in only what this part contains.", systemPrompt, len(chunks), i+1)
+		result, chunkUsage, err := c.extractChunk(chunk, prompt, schema)
+		usage.Add(chunkUsage)
+		if err != nil {
+			return "", usage, fmt.Errorf("part %d of %d: %w", i+1, len(chunks), err)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(result), &value); err != nil {
+			return "", usage, err
+		}
+		merged = mergeExtractions(merged, value)
+	}
+
+	if err := schema.Validate(merged); err != nil {
+		return "", usage, fmt.Errorf("merged extraction of %d parts is invalid: %w", len(chunks), err)
+	}
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return "", usage, err
+	}
+	result := string(data)
+	c.store(key, result)
+	return result, usage, nil
+}
+
+// extractChunk extracts data from content that fits in a single request,
+// retrying invalid responses
+func (c *Client) extractChunk(content string, systemPrompt string, schema *Schema) (string, Usage, error) {
+	messages := []Message{{Role: "user", Content: content}}
+
+	var usage Usage
+	var lastErr error
+	for attempt := 0; attempt < maxExtractAttempts; attempt++ {
+		resp, err := c.send(Request{
+			System:   systemPrompt,
+			Messages: messages,
+			Schema:   schema.Raw(),
+		})
+		if err != nil {
+			return "", usage, err
+		}
+		usage.Add(resp.Usage)
+
+		result, err := parseExtraction(resp.Content, schema)
+		if err == nil {
+			return result, usage, nil
+		}
+		lastErr = err
+
+		// Show the model its output and the problem, and ask again
+		messages = append(messages,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: fmt.Sprintf("That response is invalid: %v. Respond again with only a JSON document that conforms to the schema.", err)},
+		)
+	}
+
+	return "", usage, fmt.Errorf("invalid extraction after %d attempts: %w", maxExtractAttempts, lastErr)
+}
+
+// mergeExtractions combines the data extracted from two parts of a document.
+// Objects are merged field by field, arrays are concatenated without
+// duplicates, and other values keep the first non-empty value.
+func mergeExtractions(a, b interface{}) interface{} {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			return a
+		}
+		for k, v := range bv {
+			av[k] = mergeExtractions(av[k], v)
+		}
+		return av
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			return a
+		}
+	items:
+		for _, item := range bv {
+			for _, existing := range av {
+				if reflect.DeepEqual(existing, item) {
+					continue items
+				}
+			}
+			av = append(av, item)
+		}
+		return av
+	case string:
+		if av == "" {
+			return b
+		}
+	}
+	return a
+}
+
+// parseExtraction decodes a model response as JSON, validates it against the
+// schema and returns it indented
+func parseExtraction(text string, schema *Schema) (string, error) {
+	text = stripCodeFence(text)
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return "", fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if err := schema.Validate(value); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(text), "", "  "); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// stripCodeFence removes the markdown code fence models often wrap JSON in
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	return text
+}
@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const productSchema = `{
+	"type": "object",
+	"required": ["name", "price"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"price": {"type": "number", "minimum": 0},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"additionalProperties": false
+}`
+
+func TestParseExtraction(t *testing.T) {
+	schema, err := ParseSchema([]byte(productSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			text: `{"name": "Widget", "price": 9.5}`,
+			want: "{\n  \"name\": \"Widget\",\n  \"price\": 9.5\n}",
+		},
+		{
+			name: "code fence",
+			text: "```json\n{\"name\": \"Widget\", \"price\": 1}\n```",
+			want: "{\n  \"name\": \"Widget\",\n  \"price\": 1\n}",
+		},
+		{
+			name: "array",
+			text: `{"name": "Widget", "price": 1, "tags": ["a"]}`,
+			want: "{\n  \"name\": \"Widget\",\n  \"price\": 1,\n  \"tags\": [\n    \"a\"\n  ]\n}",
+		},
+		{name: "not JSON", text: "Widget costs 9.50", wantErr: true},
+		{name: "missing required property", text: `{"name": "Widget"}`, wantErr: true},
+		{name: "wrong type", text: `{"name": "Widget", "price": "9.50"}`, wantErr: true},
+		{name: "below minimum", text: `{"name": "Widget", "price": -1}`, wantErr: true},
+		{name: "unexpected property", text: `{"name": "Widget", "price": 1, "color": "red"}`, wantErr: true},
+		{name: "wrong item type", text: `{"name": "Widget", "price": 1, "tags": [1]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseExtraction(tt.text, schema)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{"a": 1}`, `{"a": 1}`},
+		{"  {\"a\": 1}\n", `{"a": 1}`},
+		{"```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"```\n{\"a\": 1}\n```\n", `{"a": 1}`},
+		{"```json\n\n  {\"a\": 1}  \n\n```", `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		if got := stripCodeFence(tt.text); got != tt.want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMergeExtractions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"objects merge by field", `{"name": "Widget"}`, `{"price": 2}`, `{"name": "Widget", "price": 2}`},
+		{"first value wins", `{"name": "Widget"}`, `{"name": "Gadget"}`, `{"name": "Widget"}`},
+		{"empty strings are filled", `{"name": ""}`, `{"name": "Gadget"}`, `{"name": "Gadget"}`},
+		{"arrays concatenate without duplicates", `{"tags": ["a", "b"]}`, `{"tags": ["b", "c"]}`, `{"tags": ["a", "b", "c"]}`},
+		{"nested objects", `{"spec": {"size": 1}}`, `{"spec": {"weight": 2}}`, `{"spec": {"size": 1, "weight": 2}}`},
+		{"null is replaced", `{"name": null}`, `{"name": "Gadget"}`, `{"name": "Gadget"}`},
+		{"mismatched types keep the first", `{"tags": ["a"]}`, `{"tags": "b"}`, `{"tags": ["a"]}`},
+	}
+
+	decode := func(s string) interface{} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	for _, tt := range tests {
+		got := mergeExtractions(decode(tt.a), decode(tt.b))
+		if want := decode(tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OllamaProvider talks to a local Ollama /api/chat endpoint
+type OllamaProvider struct {
+	endpoint string
+	model    string
+	client   *http.Client
+}
+
+// ollamaRequest is the body of an Ollama chat request
+type ollamaRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   json.RawMessage        `json:"format,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// ollamaResponse is the body of a non-streaming Ollama chat response
+type ollamaResponse struct {
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+}
+
+// ollamaError is the error body returned by Ollama
+type ollamaError struct {
+	Error string `json:"error"`
+}
+
+// Name returns the provider identifier
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// Complete sends a chat request to Ollama
+func (p *OllamaProvider) Complete(req Request) (*Response, error) {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	body := ollamaRequest{
+		Model:    p.model,
+		Messages: messages,
+		Stream:   false,
+		Format:   req.Schema,
+	}
+	if req.MaxTokens > 0 {
+		body.Options = map[string]interface{}{"num_predict": req.MaxTokens}
+	}
+
+	resp, respBody, err := postJSON(p.client, p.endpoint+"/api/chat", nil, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.classify(resp, respBody)
+	}
+
+	var chatResp ollamaResponse
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return &Response{
+		Content: chatResp.Message.Content,
+		Usage: Usage{
+			PromptTokens:     chatResp.PromptEvalCount,
+			CompletionTokens: chatResp.EvalCount,
+		},
+	}, nil
+}
+
+// classify maps an Ollama error response to an APIError
+func (p *OllamaProvider) classify(resp *http.Response, body []byte) *APIError {
+	apiErr := newAPIError(p.Name(), resp)
+
+	var errBody ollamaError
+	if json.Unmarshal(body, &errBody) == nil {
+		apiErr.Message = errBody.Error
+		if resp.StatusCode == http.StatusNotFound && strings.Contains(errBody.Error, "model") {
+			apiErr.Kind = ErrorKindInvalidRequest
+		}
+	}
+
+	return apiErr
+}
+
+// ollamaEmbedRequest is the body of an Ollama /api/embed request
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse is the body of an Ollama /api/embed response
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// Embed sends an embedding request to Ollama
+func (p *OllamaProvider) Embed(model string, texts []string) ([][]float32, Usage, error) {
+	resp, body, err := postJSON(p.client, p.endpoint+"/api/embed", nil, ollamaEmbedRequest{
+		Model: model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, Usage{}, p.classify(resp, body)
+	}
+
+	var embResp ollamaEmbedResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, Usage{}, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return embResp.Embeddings, Usage{PromptTokens: embResp.PromptEvalCount}, nil
+}
@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOllamaComplete(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{
+		"message": {"role": "assistant", "content": "{\"name\": \"Widget\"}"},
+		"done": true,
+		"prompt_eval_count": 42,
+		"eval_count": 7
+	}`)
+	p := newTestProvider(t, "ollama", "llama3.2", s)
+
+	schema := json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}}}`)
+	resp, err := p.Complete(Request{
+		System:    "Extract the product.",
+		Messages:  []Message{{Role: "user", Content: "Page text"}},
+		MaxTokens: 300,
+		Schema:    schema,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.path != "/api/chat" {
+		t.Errorf("path = %s", s.path)
+	}
+	if got := s.header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+	want := jsonValue(t, `{
+		"model": "llama3.2",
+		"messages": [
+			{"role": "system", "content": "Extract the product."},
+			{"role": "user", "content": "Page text"}
+		],
+		"stream": false,
+		"format": `+string(schema)+`,
+		"options": {"num_predict": 300}
+	}`)
+	if !reflect.DeepEqual(interface{}(s.body), want) {
+		t.Errorf("request body = %v, want %v", s.body, want)
+	}
+
+	if resp.Content != `{"name": "Widget"}` || resp.Usage != (Usage{PromptTokens: 42, CompletionTokens: 7}) {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestOllamaPlainRequest(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{"message": {"role": "assistant", "content": "ok"}, "done": true}`)
+	if _, err := newTestProvider(t, "ollama", "llama3.2", s).Complete(Request{
+		Messages: []Message{{Role: "user", Content: "Page text"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"format", "options"} {
+		if _, ok := s.body[field]; ok {
+			t.Errorf("unexpected %s field in %v", field, s.body)
+		}
+	}
+}
+
+func TestOllamaErrors(t *testing.T) {
+	testErrors(t, "ollama", []errorCase{
+		{
+			name:    "unknown model",
+			status:  http.StatusNotFound,
+			body:    `{"error": "model \"llama9\" not found, try pulling it first"}`,
+			kind:    ErrorKindInvalidRequest,
+			message: `model "llama9" not found, try pulling it first`,
+		},
+		{
+			name:       "busy",
+			status:     http.StatusServiceUnavailable,
+			header:     http.Header{"Retry-After": {"3"}},
+			body:       `{"error": "server busy, please try again"}`,
+			kind:       ErrorKindOverloaded,
+			retryAfter: 3 * time.Second,
+			message:    "server busy, please try again",
+		},
+		{
+			name:    "server",
+			status:  http.StatusInternalServerError,
+			body:    `{"error": "llama runner process has terminated"}`,
+			kind:    ErrorKindServer,
+			message: "llama runner process has terminated",
+		},
+	})
+}
+
+func TestOllamaEmbed(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{"embeddings": [[0.1, 0.2], [0.3, 0.4]], "prompt_eval_count": 9}`)
+	p := newTestProvider(t, "ollama", "llama3.2", s).(Embedder)
+
+	vectors, usage, err := p.Embed("nomic-embed-text", []string{"first", "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.path != "/api/embed" {
+		t.Errorf("path = %s", s.path)
+	}
+	if want := jsonValue(t, `{"model": "nomic-embed-text", "input": ["first", "second"]}`); !reflect.DeepEqual(interface{}(s.body), want) {
+		t.Errorf("request body = %v, want %v", s.body, want)
+	}
+	if want := [][]float32{{0.1, 0.2}, {0.3, 0.4}}; !reflect.DeepEqual(vectors, want) {
+		t.Errorf("vectors = %v, want %v", vectors, want)
+	}
+	if usage.PromptTokens != 9 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
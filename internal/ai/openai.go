@@ -0,0 +1,212 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAIProvider talks to OpenAI-compatible /chat/completions endpoints
+type OpenAIProvider struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// ChatRequest represents a request to the chat completion API
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// responseFormat requests structured JSON output
+type responseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *jsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// jsonSchemaFormat names the schema the response must conform to. Strict is
+// left off: strict mode rejects schemas that do not require every property,
+// forbid additional properties at every level and avoid keywords such as
+// minLength or pattern, which rules out most hand-written schemas. Responses
+// are validated against the full schema afterwards instead.
+type jsonSchemaFormat struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// structuredOutputModels are the prefixes of OpenAI models that accept a
+// json_schema response format. Other models are asked for a JSON object and
+// the response is validated against the schema afterwards.
+var structuredOutputModels = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4"}
+
+// supportsStructuredOutputs reports whether a model accepts a json_schema
+// response format
+func supportsStructuredOutputs(model string) bool {
+	// The first gpt-4o snapshot predates structured outputs
+	if model == "gpt-4o-2024-05-13" || strings.HasPrefix(model, "o1-preview") || strings.HasPrefix(model, "o1-mini") {
+		return false
+	}
+	for _, prefix := range structuredOutputModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatResponse represents a response from the chat completion API
+type ChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIError is the error body returned by OpenAI-compatible APIs
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// Name returns the provider identifier
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Complete sends a chat completion request
+func (p *OpenAIProvider) Complete(req Request) (*Response, error) {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", p.apiKey),
+	}
+
+	chatReq := ChatRequest{
+		Model:     p.model,
+		Messages:  messages,
+		MaxTokens: req.MaxTokens,
+	}
+	switch {
+	case len(req.Schema) == 0:
+	case supportsStructuredOutputs(p.model):
+		chatReq.ResponseFormat = &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &jsonSchemaFormat{Name: "extraction", Schema: req.Schema},
+		}
+	default:
+		chatReq.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
+	resp, body, err := postJSON(p.client, p.endpoint+"/chat/completions", headers, chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.classify(resp, body)
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	return &Response{
+		Content: chatResp.Choices[0].Message.Content,
+		Usage: Usage{
+			PromptTokens:     chatResp.Usage.PromptTokens,
+			CompletionTokens: chatResp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+// classify maps an OpenAI error response to an APIError
+func (p *OpenAIProvider) classify(resp *http.Response, body []byte) *APIError {
+	apiErr := newAPIError(p.Name(), resp)
+
+	var errBody openAIError
+	if json.Unmarshal(body, &errBody) == nil {
+		apiErr.Message = errBody.Error.Message
+		switch {
+		case errBody.Error.Type == "insufficient_quota" || errBody.Error.Code == "insufficient_quota":
+			apiErr.Kind = ErrorKindQuota
+		case errBody.Error.Code == "context_length_exceeded" || strings.Contains(errBody.Error.Message, "maximum context length"):
+			apiErr.Kind = ErrorKindContextLength
+		}
+	}
+
+	return apiErr
+}
+
+// embeddingRequest is the body of an /embeddings request
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// embeddingResponse is the body of an /embeddings response
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
+
+// Embed sends an /embeddings request
+func (p *OpenAIProvider) Embed(model string, texts []string) ([][]float32, Usage, error) {
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", p.apiKey),
+	}
+
+	resp, body, err := postJSON(p.client, p.endpoint+"/embeddings", headers, embeddingRequest{
+		Model: model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, Usage{}, p.classify(resp, body)
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, Usage{}, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, Usage{}, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	return vectors, Usage{PromptTokens: embResp.Usage.PromptTokens}, nil
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const openAIReply = `{
+	"choices": [{"message": {"role": "assistant", "content": "A summary."}}],
+	"usage": {"prompt_tokens": 42, "completion_tokens": 7}
+}`
+
+func TestOpenAIComplete(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, openAIReply)
+	p := newTestProvider(t, "openai", "gpt-3.5-turbo", s)
+
+	resp, err := p.Complete(Request{
+		System:    "Be brief.",
+		Messages:  []Message{{Role: "user", Content: "Page text"}},
+		MaxTokens: 300,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.path != "/chat/completions" {
+		t.Errorf("path = %s", s.path)
+	}
+	if got := s.header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Authorization = %q", got)
+	}
+	want := jsonValue(t, `{
+		"model": "gpt-3.5-turbo",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "Page text"}
+		],
+		"max_tokens": 300
+	}`)
+	if !reflect.DeepEqual(interface{}(s.body), want) {
+		t.Errorf("request body = %v, want %v", s.body, want)
+	}
+
+	if resp.Content != "A summary." || resp.Usage != (Usage{PromptTokens: 42, CompletionTokens: 7}) {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestOpenAIResponseFormat(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","properties":{"name":{"type":"string","minLength":1}}}`)
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o-mini", `{"type": "json_schema", "json_schema": {"name": "extraction", "schema": ` + string(schema) + `, "strict": false}}`},
+		{"gpt-4o-2024-05-13", `{"type": "json_object"}`},
+		{"gpt-3.5-turbo", `{"type": "json_object"}`},
+	}
+
+	for _, tt := range tests {
+		s := newStandIn(t, http.StatusOK, nil, openAIReply)
+		if _, err := newTestProvider(t, "openai", tt.model, s).Complete(Request{
+			Messages: []Message{{Role: "user", Content: "Page text"}},
+			Schema:   schema,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := s.body["response_format"], jsonValue(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: response_format = %v, want %v", tt.model, got, want)
+		}
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	testErrors(t, "openai", []errorCase{
+		{
+			name:       "rate limit",
+			status:     http.StatusTooManyRequests,
+			header:     http.Header{"Retry-After": {"12"}, "X-Ratelimit-Reset-Requests": {"1m"}},
+			body:       `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			kind:       ErrorKindRateLimit,
+			retryAfter: 12 * time.Second,
+			message:    "Rate limit reached",
+		},
+		{
+			name:    "quota",
+			status:  http.StatusTooManyRequests,
+			body:    `{"error": {"message": "You exceeded your current quota", "type": "insufficient_quota"}}`,
+			kind:    ErrorKindQuota,
+			message: "You exceeded your current quota",
+		},
+		{
+			name:    "context length",
+			status:  http.StatusBadRequest,
+			header:  http.Header{"X-Ratelimit-Reset-Tokens": {"30s"}},
+			body:    `{"error": {"message": "This model's maximum context length is 16385 tokens", "code": "context_length_exceeded"}}`,
+			kind:    ErrorKindContextLength,
+			message: "This model's maximum context length is 16385 tokens",
+		},
+		{
+			name:    "auth",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"message": "Incorrect API key provided"}}`,
+			kind:    ErrorKindAuth,
+			message: "Incorrect API key provided",
+		},
+		{
+			name:   "server",
+			status: http.StatusBadGateway,
+			body:   `bad gateway`,
+			kind:   ErrorKindServer,
+		},
+	})
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	s := newStandIn(t, http.StatusOK, nil, `{
+		"data": [{"embedding": [0.3, 0.4], "index": 1}, {"embedding": [0.1, 0.2], "index": 0}],
+		"usage": {"prompt_tokens": 9}
+	}`)
+	p := newTestProvider(t, "openai", "gpt-3.5-turbo", s).(Embedder)
+
+	vectors, usage, err := p.Embed("text-embedding-3-small", []string{"first", "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.path != "/embeddings" {
+		t.Errorf("path = %s", s.path)
+	}
+	if want := jsonValue(t, `{"model": "text-embedding-3-small", "input": ["first", "second"]}`); !reflect.DeepEqual(interface{}(s.body), want) {
+		t.Errorf("request body = %v, want %v", s.body, want)
+	}
+	if want := [][]float32{{0.1, 0.2}, {0.3, 0.4}}; !reflect.DeepEqual(vectors, want) {
+		t.Errorf("vectors = %v, want %v", vectors, want)
+	}
+	if usage.PromptTokens != 9 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Provider sends completion requests to a specific model API
+type Provider interface {
+	// Name returns the provider identifier used in configuration
+	Name() string
+
+	// Complete sends a request and returns the generated response
+	Complete(req Request) (*Response, error)
+}
+
+// Request is a provider-independent completion request
+type Request struct {
+	System    string
+	Messages  []Message
+	MaxTokens int // 0 uses the provider default
+
+	// Schema requests JSON output conforming to the schema from providers
+	// that support structured output natively
+	Schema json.RawMessage
+}
+
+// Response is a provider-independent completion response
+type Response struct {
+	Content string
+	Usage   Usage // tokens reported by the provider, zero when unknown
+}
+
+// defaultModels are the models used when none is configured, by provider
+var defaultModels = map[string]string{
+	"openai":    "gpt-3.5-turbo",
+	"anthropic": "claude-3-5-haiku-latest",
+	"ollama":    "llama3.2",
+}
+
+// DefaultModel returns the model used with a provider when none is
+// configured, or "" for an unknown provider
+func DefaultModel(provider string) string {
+	name := strings.ToLower(provider)
+	if name == "" {
+		name = "openai"
+	}
+	return defaultModels[name]
+}
+
+// NewProvider creates the provider selected by opts.Provider
+func NewProvider(opts Options) (Provider, error) {
+	client := &http.Client{}
+
+	switch strings.ToLower(opts.Provider) {
+	case "", "openai":
+		if opts.APIKey == "" {
+			return nil, fmt.Errorf("AI API key is required for the openai provider")
+		}
+		return &OpenAIProvider{
+			endpoint: endpointOrDefault(opts.Endpoint, "https://api.openai.com/v1"),
+			apiKey:   opts.APIKey,
+			model:    opts.Model,
+			client:   client,
+		}, nil
+	case "anthropic":
+		if opts.APIKey == "" {
+			return nil, fmt.Errorf("AI API key is required for the anthropic provider")
+		}
+		return &AnthropicProvider{
+			endpoint: endpointOrDefault(opts.Endpoint, "https://api.anthropic.com/v1"),
+			apiKey:   opts.APIKey,
+			model:    opts.Model,
+			client:   client,
+		}, nil
+	case "ollama":
+		return &OllamaProvider{
+			endpoint: endpointOrDefault(opts.Endpoint, "http://localhost:11434"),
+			model:    opts.Model,
+			client:   client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported AI provider: %s (use openai, anthropic or ollama)", opts.Provider)
+	}
+}
+
+// endpointOrDefault trims a configured endpoint, falling back to the default
+func endpointOrDefault(endpoint string, fallback string) string {
+	if endpoint == "" {
+		return fallback
+	}
+	return strings.TrimRight(endpoint, "/")
+}
+
+// postJSON sends a JSON request and returns the response status and body
+func postJSON(client *http.Client, url string, headers map[string]string, body interface{}) (*http.Response, []byte, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	return resp, respBody, nil
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// standIn is a local HTTP server playing a provider API. It answers every
+// request with a fixed response and records the last request it received.
+type standIn struct {
+	*httptest.Server
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newStandIn starts a stand-in answering with status, headers and body
+func newStandIn(t *testing.T, status int, header http.Header, body string) *standIn {
+	t.Helper()
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.path = r.URL.Path
+		s.header = r.Header.Clone()
+		data, _ := io.ReadAll(r.Body)
+		s.body = nil
+		if err := json.Unmarshal(data, &s.body); err != nil {
+			t.Errorf("request body is not a JSON object: %v", err)
+		}
+
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestProvider creates a provider sending its requests to the stand-in
+func newTestProvider(t *testing.T, name string, model string, s *standIn) Provider {
+	t.Helper()
+	p, err := NewProvider(Options{Provider: name, Endpoint: s.URL, APIKey: "test-key", Model: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// jsonValue decodes a JSON literal for comparison with a recorded body
+func jsonValue(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// apiError asserts that err is an APIError and returns it
+func apiError(t *testing.T, err error) *APIError {
+	t.Helper()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	return apiErr
+}
+
+// testErrors checks how a provider maps error responses
+func testErrors(t *testing.T, provider string, tests []errorCase) {
+	t.Helper()
+	for _, tt := range tests {
+		s := newStandIn(t, tt.status, tt.header, tt.body)
+		_, err := newTestProvider(t, provider, "model", s).Complete(Request{Messages: []Message{{Role: "user", Content: "hi"}}})
+		apiErr := apiError(t, err)
+		if apiErr.StatusCode != tt.status || apiErr.Kind != tt.kind || apiErr.RetryAfter != tt.retryAfter || apiErr.Message != tt.message {
+			t.Errorf("%s: got status %d, kind %s, retry after %v, message %q; want %d, %s, %v, %q",
+				tt.name, apiErr.StatusCode, apiErr.Kind, apiErr.RetryAfter, apiErr.Message,
+				tt.status, tt.kind, tt.retryAfter, tt.message)
+		}
+	}
+}
+
+// errorCase is an error response and the APIError it should map to
+type errorCase struct {
+	name       string
+	status     int
+	header     http.Header
+	body       string
+	kind       ErrorKind
+	retryAfter time.Duration
+	message    string
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket shared by every request of a client. It enforces
+// a requests-per-minute and a tokens-per-minute budget, and pauses all
+// requests when a provider asks clients to back off.
+type Limiter struct {
+	mu          sync.Mutex
+	rpm         float64 // 0 for no limit
+	tpm         float64 // 0 for no limit
+	requests    float64 // requests available now
+	tokens      float64 // tokens available now
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewLimiter creates a limiter allowing rpm requests and tpm tokens per
+// minute. A limit of 0 disables that budget. The buckets start full.
+func NewLimiter(rpm int, tpm int) *Limiter {
+	return &Limiter{
+		rpm:      float64(rpm),
+		tpm:      float64(tpm),
+		requests: float64(rpm),
+		tokens:   float64(tpm),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a request of the given estimated size fits both budgets
+// and reserves it
+func (l *Limiter) Wait(tokens int) {
+	for {
+		l.mu.Lock()
+		wait := l.reserve(float64(tokens), time.Now())
+		l.mu.Unlock()
+
+		if wait <= 0 {
+			return
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Record corrects the token budget once the actual size of a request is
+// known, since reservations are made from estimates
+func (l *Limiter) Record(estimated int, actual int) {
+	if actual <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tpm > 0 {
+		l.tokens -= float64(actual - estimated)
+	}
+}
+
+// Pause holds back all requests for d, as asked for by a provider's
+// Retry-After or rate limit reset headers
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// reserve takes a request from the buckets, or returns how long to wait
+// before trying again
+func (l *Limiter) reserve(tokens float64, now time.Time) time.Duration {
+	l.refill(now)
+
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	// A request larger than the whole token budget only needs a full bucket
+	if l.tpm > 0 && tokens > l.tpm {
+		tokens = l.tpm
+	}
+
+	var wait time.Duration
+	if l.rpm > 0 && l.requests < 1 {
+		wait = deficitWait(1-l.requests, l.rpm)
+	}
+	if l.tpm > 0 && l.tokens < tokens {
+		if w := deficitWait(tokens-l.tokens, l.tpm); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if l.rpm > 0 {
+		l.requests--
+	}
+	if l.tpm > 0 {
+		l.tokens -= tokens
+	}
+	return 0
+}
+
+// refill adds the budget accrued since the last call, up to one minute's worth
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	l.requests = min(l.rpm, l.requests+elapsed*l.rpm)
+	l.tokens = min(l.tpm, l.tokens+elapsed*l.tpm)
+}
+
+// deficitWait returns how long a bucket refilling at perMinute takes to
+// accrue the missing amount
+func deficitWait(missing float64, perMinute float64) time.Duration {
+	wait := time.Duration(missing / perMinute * float64(time.Minute))
+	if wait < time.Millisecond {
+		wait = time.Millisecond
+	}
+	return wait
+}
@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema used to validate extraction results:
+// type, properties, required, additionalProperties, items, enum, string
+// length and pattern, numeric bounds and array length.
+type Schema struct {
+	Type                 interface{}        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	raw json.RawMessage
+}
+
+// ParseSchema parses a JSON Schema document
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	s.raw = compact.Bytes()
+
+	return &s, nil
+}
+
+// Raw returns the schema document as it was parsed
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Validate checks a decoded JSON value against the schema
+func (s *Schema) Validate(v interface{}) error {
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(at string, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	if types := s.types(); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesType(t, v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", at, strings.Join(types, " or "), jsonType(v))
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of the allowed values", at, v)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+		for name, child := range val {
+			if prop, ok := s.Properties[name]; ok {
+				if err := prop.validate(at+"."+name, child); err != nil {
+					return err
+				}
+				continue
+			}
+			if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+				return fmt.Errorf("%s: unexpected property %q", at, name)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items", at, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items", at, *s.MaxItems)
+		}
+		for i, item := range val {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); err != nil {
+				return err
+			}
+		}
+	case string:
+		length := len([]rune(val))
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters", at, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters", at, *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern in schema: %w", at, err)
+			}
+			if !re.MatchString(val) {
+				return fmt.Errorf("%s: value does not match pattern %s", at, s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			return fmt.Errorf("%s: expected a value of at least %v", at, *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			return fmt.Errorf("%s: expected a value of at most %v", at, *s.Maximum)
+		}
+	}
+
+	return nil
+}
+
+// types returns the allowed types of the schema
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesType checks a decoded JSON value against a JSON Schema type name
+func matchesType(t string, v interface{}) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	default:
+		return jsonType(v) == t
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
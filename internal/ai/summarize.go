@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultMaxRequestTokens is used when Options.MaxRequestTokens is not set
+	DefaultMaxRequestTokens = 12000
+
+	// promptOverheadTokens reserves room for message framing and chunk notes
+	promptOverheadTokens = 200
+
+	// minChunkTokens keeps chunks useful when the system prompt is very large
+	minChunkTokens = 500
+)
+
+// reduceInstruction is appended to the system prompt for the reduce step
+const reduceInstruction = `
+
+The input consists of partial results produced from consecutive parts of a single document, separated by "---". Merge them into one coherent result that follows the instructions above, removing duplication.`
+
+// Summarize generates a summary of the provided content using the AI model.
+// Content that does not fit in a single request is split along markdown
+// headings, each chunk is summarized, and the partial summaries are combined.
+// The returned usage covers every request made, including failed attempts,
+// and is zero for cached results.
+func (c *Client) Summarize(content string, systemPrompt string) (string, Usage, error) {
+	key := c.cacheKey(content, systemPrompt)
+	if result, ok := c.cached(key); ok {
+		return result, Usage{}, nil
+	}
+
+	var usage Usage
+	result, err := c.summarize(content, systemPrompt, &usage)
+	if err != nil {
+		return "", usage, err
+	}
+
+	c.store(key, result)
+	return result, usage, nil
+}
+
+// summarize runs the single request or map-reduce summarization
+func (c *Client) summarize(content string, systemPrompt string, usage *Usage) (string, error) {
+	budget := c.chunkBudget(systemPrompt)
+	if EstimateTokens(content) <= budget {
+		return c.complete(systemPrompt, content, usage)
+	}
+
+	chunks := ChunkMarkdown(content, budget)
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		note := fmt.Sprintf("This is part %d of %d of a longer document.\n\n", i+1, len(chunks))
+		summary, err := c.complete(systemPrompt, note+chunk, usage)
+		if err != nil {
+			return "", fmt.Errorf("error summarizing part %d of %d: %w", i+1, len(chunks), err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return c.reduce(summaries, systemPrompt, usage)
+}
+
+// reduce combines partial summaries into one, merging in groups that fit the
+// request budget until a single request can hold them all
+func (c *Client) reduce(summaries []string, systemPrompt string, usage *Usage) (string, error) {
+	prompt := systemPrompt + reduceInstruction
+	budget := c.chunkBudget(prompt)
+
+	for {
+		combined := strings.Join(summaries, "\n\n---\n\n")
+		if len(summaries) == 1 || EstimateTokens(combined) <= budget {
+			return c.complete(prompt, combined, usage)
+		}
+
+		var groups [][]string
+		var current []string
+		currentTokens := 0
+		for _, summary := range summaries {
+			tokens := EstimateTokens(summary)
+			if currentTokens+tokens > budget && len(current) > 0 {
+				groups = append(groups, current)
+				current = nil
+				currentTokens = 0
+			}
+			current = append(current, summary)
+			currentTokens += tokens
+		}
+		groups = append(groups, current)
+
+		// Every summary is too large to pair with another, one final
+		// request is the best that can be done
+		if len(groups) == len(summaries) {
+			return c.complete(prompt, combined, usage)
+		}
+
+		next := make([]string, 0, len(groups))
+		for _, group := range groups {
+			if len(group) == 1 {
+				next = append(next, group[0])
+				continue
+			}
+			merged, err := c.complete(prompt, strings.Join(group, "\n\n---\n\n"), usage)
+			if err != nil {
+				return "", fmt.Errorf("error combining partial summaries: %w", err)
+			}
+			next = append(next, merged)
+		}
+		summaries = next
+	}
+}
+
+// complete sends a single system/user request and returns the response text,
+// adding the tokens used to usage
+func (c *Client) complete(systemPrompt string, content string, usage *Usage) (string, error) {
+	resp, err := c.send(Request{
+		System: systemPrompt,
+		Messages: []Message{
+			{
+				Role:    "user",
+				Content: content,
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	usage.Add(resp.Usage)
+
+	if resp.Content == "" {
+		return "", fmt.Errorf("no summary generated")
+	}
+
+	return resp.Content, nil
+}
+
+// chunkBudget returns the estimated tokens available for content in a
+// request using the given system prompt
+func (c *Client) chunkBudget(systemPrompt string) int {
+	budget := c.maxRequestTokens - EstimateTokens(systemPrompt) - promptOverheadTokens
+	if budget < minChunkTokens {
+		budget = minChunkTokens
+	}
+	return budget
+}
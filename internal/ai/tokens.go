@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EstimateTokens approximates the number of model tokens in text without
+// calling a tokenizer. ASCII text averages about four characters per token,
+// while CJK and other non-Latin scripts are closer to one token per character.
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else if unicode.IsLetter(r) {
+			other++
+		} else {
+			ascii += 2
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// ChunkMarkdown splits markdown content into chunks of at most maxTokens
+// estimated tokens. Splits happen at heading boundaries where possible, then
+// at paragraphs and lines for sections that are too large on their own.
+// Headings inside fenced code blocks are not treated as boundaries.
+func ChunkMarkdown(content string, maxTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(content) <= maxTokens {
+		return []string{content}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+
+	flush := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			chunks = append(chunks, current.String())
+		}
+		current.Reset()
+		currentTokens = 0
+	}
+
+	for _, section := range splitSections(content) {
+		tokens := EstimateTokens(section)
+		if currentTokens+tokens <= maxTokens {
+			current.WriteString(section)
+			currentTokens += tokens
+			continue
+		}
+
+		flush()
+		if tokens <= maxTokens {
+			current.WriteString(section)
+			currentTokens = tokens
+			continue
+		}
+
+		// The section alone is too large, split it further
+		for _, part := range splitOversized(section, maxTokens) {
+			chunks = append(chunks, part)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSections splits markdown into sections starting at each heading
+func splitSections(content string) []string {
+	var sections []string
+	var current strings.Builder
+	inFence := false
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(trimmed, "#") && current.Len() > 0 {
+			sections = append(sections, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		sections = append(sections, current.String())
+	}
+
+	return sections
+}
+
+// splitOversized splits a single section by paragraphs, then lines, then raw
+// characters until every part fits within maxTokens
+func splitOversized(section string, maxTokens int) []string {
+	for _, sep := range []string{"\n\n", "\n"} {
+		var pieces []string
+		for _, piece := range strings.SplitAfter(section, sep) {
+			if piece != "" {
+				pieces = append(pieces, piece)
+			}
+		}
+		if len(pieces) < 2 {
+			continue
+		}
+
+		var parts []string
+		var current strings.Builder
+		currentTokens := 0
+		for _, piece := range pieces {
+			tokens := EstimateTokens(piece)
+			if currentTokens+tokens > maxTokens && current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+				currentTokens = 0
+			}
+			if tokens > maxTokens {
+				parts = append(parts, splitOversized(piece, maxTokens)...)
+				continue
+			}
+			current.WriteString(piece)
+			currentTokens += tokens
+		}
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+		}
+		return parts
+	}
+
+	// No line breaks left, cut the text into fixed-size pieces
+	var parts []string
+	runes := []rune(section)
+	size := maxTokens // some scripts use one token per rune
+	for len(runes) > 0 {
+		n := size
+		if n > len(runes) {
+			n = len(runes)
+		}
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return parts
+}
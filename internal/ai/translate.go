@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxTranslateChunkTokens keeps each translated part well within the output
+// limits of common models, since a translation is as long as its input
+const maxTranslateChunkTokens = 2000
+
+var (
+	// inlineCodePattern matches inline code spans
+	inlineCodePattern = regexp.MustCompile("`[^`\n]+`")
+
+	// linkTargetPattern matches the target of a markdown link or image
+	linkTargetPattern = regexp.MustCompile(`\]\(\s*<?[^)\s>]+>?(?:\s+"[^"]*")?\s*\)`)
+
+	// placeholderPattern matches the placeholders protected text is
+	// replaced with
+	placeholderPattern = regexp.MustCompile(`@@\d+@@`)
+)
+
+// translatePrompt is the system prompt for translation; %s is the target
+// language
+const translatePrompt = `You translate markdown documents into %s. Translate all prose, headings, table cells and link texts. Keep the markdown structure exactly: the same headings and heading levels, lists, tables and emphasis. Placeholders of the form @@N@@ stand for code and link targets: copy every one of them unchanged to the matching position. Respond with the translated markdown only, without any notes.`
+
+// Translate translates markdown content into the target language. Fenced
+// code blocks, inline code and link targets are replaced with placeholders
+// before the content is sent and restored afterwards, so they are never
+// altered. Content is translated in chunks split along headings. The returned
+// usage covers every request made and is zero for cached results.
+func (c *Client) Translate(content string, language string) (string, Usage, error) {
+	systemPrompt := fmt.Sprintf(translatePrompt, language)
+
+	key := c.cacheKey(content, systemPrompt)
+	if result, ok := c.cached(key); ok {
+		return result, Usage{}, nil
+	}
+
+	protected, originals := protectMarkdown(content)
+
+	var usage Usage
+	var parts []string
+	chunks := ChunkMarkdown(protected, min(c.chunkBudget(systemPrompt), maxTranslateChunkTokens))
+	for i, chunk := range chunks {
+		if strings.TrimSpace(chunk) == "" {
+			parts = append(parts, chunk)
+			continue
+		}
+		translated, err := c.complete(systemPrompt, chunk, &usage)
+		if err != nil {
+			return "", usage, fmt.Errorf("error translating part %d of %d: %w", i+1, len(chunks), err)
+		}
+		parts = append(parts, strings.TrimSpace(translated)+"\n\n")
+	}
+
+	result, err := restoreMarkdown(strings.TrimSpace(strings.Join(parts, ""))+"\n", originals)
+	if err != nil {
+		return "", usage, err
+	}
+
+	c.store(key, result)
+	return result, usage, nil
+}
+
+// protectMarkdown replaces fenced code blocks, inline code and link targets
+// with numbered placeholders, returning the text and the replaced originals
+func protectMarkdown(content string) (string, []string) {
+	var originals []string
+	placeholder := func(original string) string {
+		originals = append(originals, original)
+		return fmt.Sprintf("@@%d@@", len(originals)-1)
+	}
+
+	// Fenced code blocks are protected line by line so headings and other
+	// markdown inside them stay untouched
+	var b strings.Builder
+	var block strings.Builder
+	fence := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				block.WriteString(line)
+				continue
+			}
+			b.WriteString(line)
+			continue
+		}
+		block.WriteString(line)
+		if strings.HasPrefix(trimmed, fence) {
+			b.WriteString(placeholder(strings.TrimSuffix(block.String(), "\n")))
+			b.WriteString("\n")
+			block.Reset()
+			fence = ""
+		}
+	}
+	// An unclosed fence runs to the end of the document
+	if block.Len() > 0 {
+		b.WriteString(placeholder(block.String()))
+	}
+
+	text := inlineCodePattern.ReplaceAllStringFunc(b.String(), placeholder)
+	text = linkTargetPattern.ReplaceAllStringFunc(text, func(target string) string {
+		return "](" + placeholder(target[2:len(target)-1]) + ")"
+	})
+	return text, originals
+}
+
+// restoreMarkdown puts the protected originals back in place of their
+// placeholders, failing when the model dropped any of them
+func restoreMarkdown(text string, originals []string) (string, error) {
+	seen := make([]bool, len(originals))
+	text = placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		var i int
+		if _, err := fmt.Sscanf(p, "@@%d@@", &i); err != nil || i >= len(originals) {
+			return p
+		}
+		seen[i] = true
+		return originals[i]
+	})
+
+	missing := 0
+	for _, ok := range seen {
+		if !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return "", fmt.Errorf("translation lost %d of %d code blocks or links", missing, len(originals))
+	}
+	return text, nil
+}
@@ -0,0 +1,29 @@
+package ai
+
+// Usage counts the tokens consumed by AI requests
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Add accumulates the tokens of another request
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+}
+
+// Total returns the combined prompt and completion tokens
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Price is the cost of a model per million prompt and completion tokens
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// Cost returns the cost of the given usage
+func (p Price) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -21,19 +26,83 @@ type CrawlerConfig struct {
 	IgnoreExts     []string `mapstructure:"ignore_extensions"`
 	RescanInterval string   `mapstructure:"rescan_interval"`
 	Parallelism    int      `mapstructure:"parallelism"`
+	Compression    string   `mapstructure:"compression"`
+	Relink         bool     `mapstructure:"relink"`
 	ReaderAPI      struct {
 		URL     string            `mapstructure:"url"`
 		Headers map[string]string `mapstructure:"headers"`
 	} `mapstructure:"reader_api"`
+	Queue struct {
+		Strategy      string      `mapstructure:"strategy"`
+		Sitemap       string      `mapstructure:"sitemap"`
+		InboundWeight float64     `mapstructure:"inbound_weight"`
+		Weights       []URLWeight `mapstructure:"weights"`
+	} `mapstructure:"queue"`
+	Index struct {
+		Enabled bool `mapstructure:"enabled"`
+		HTML    bool `mapstructure:"html"`
+	} `mapstructure:"index"`
+	Assets struct {
+		Enabled        bool     `mapstructure:"enabled"`
+		Attachments    bool     `mapstructure:"attachments"`
+		AttachmentExts []string `mapstructure:"attachment_extensions"`
+	} `mapstructure:"assets"`
 	AI struct {
-		Enabled      bool   `mapstructure:"enabled"`
-		Endpoint     string `mapstructure:"endpoint"`
-		APIKey       string `mapstructure:"api_key"`
-		Model        string `mapstructure:"model"`
-		SystemPrompt string `mapstructure:"system_prompt"`
+		Enabled          bool   `mapstructure:"enabled"`
+		Provider         string `mapstructure:"provider"`
+		Endpoint         string `mapstructure:"endpoint"`
+		APIKey           string `mapstructure:"api_key"`
+		Model            string `mapstructure:"model"`
+		EmbeddingModel   string `mapstructure:"embedding_model"`
+		SystemPrompt     string `mapstructure:"system_prompt"`
+		MaxRequestTokens int    `mapstructure:"max_request_tokens"`
+		Mode             string `mapstructure:"mode"`
+		Extract          struct {
+			SchemaFile string `mapstructure:"schema_file"`
+			Prompt     string `mapstructure:"prompt"`
+		} `mapstructure:"extract"`
+		Tagging struct {
+			Enabled  bool     `mapstructure:"enabled"`
+			Taxonomy []string `mapstructure:"taxonomy"`
+		} `mapstructure:"tagging"`
+		Translation struct {
+			Language string `mapstructure:"language"`
+		} `mapstructure:"translation"`
+		Prompts           []PromptRule `mapstructure:"prompts"`
+		Prices            []ModelPrice `mapstructure:"prices"`
+		Budget            float64      `mapstructure:"budget"`
+		RequestsPerMinute int          `mapstructure:"requests_per_minute"`
+		TokensPerMinute   int          `mapstructure:"tokens_per_minute"`
 	} `mapstructure:"ai"`
 }
 
+// PromptRule selects a prompt template (Go text/template) for pages whose
+// URL matches a glob or regular expression. Template files are resolved
+// relative to the config file.
+type PromptRule struct {
+	Pattern  string `mapstructure:"pattern"`
+	Regex    string `mapstructure:"regex"`
+	Template string `mapstructure:"template"`
+	File     string `mapstructure:"file"`
+}
+
+// URLWeight adds to the queue priority of pages whose URL matches a glob or
+// regular expression
+type URLWeight struct {
+	Pattern string  `mapstructure:"pattern"`
+	Regex   string  `mapstructure:"regex"`
+	Weight  float64 `mapstructure:"weight"`
+}
+
+// ModelPrice is the cost of a model per million prompt and completion tokens.
+// Prices are a list rather than a map keyed by model because model names
+// often contain dots, which viper treats as key separators.
+type ModelPrice struct {
+	Model      string  `mapstructure:"model"`
+	Prompt     float64 `mapstructure:"prompt"`
+	Completion float64 `mapstructure:"completion"`
+}
+
 // HTTPConfig holds HTTP client settings
 type HTTPConfig struct {
 	Timeout       int    `mapstructure:"timeout"`
@@ -43,6 +112,47 @@ type HTTPConfig struct {
 	RequestDelay  int    `mapstructure:"request_delay"`
 }
 
+// FindConfigFile looks for config in standard locations
+func FindConfigFile(configPath string) string {
+	// Check explicit path first
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath
+		}
+	}
+
+	// Check standard locations
+	locations := []string{
+		".stripper.yaml",
+		path.Join(os.Getenv("HOME"), ".stripper.yaml"),
+		"/etc/stripper/config.yaml",
+	}
+
+	for _, loc := range locations {
+		if _, err := os.Stat(loc); err == nil {
+			return loc
+		}
+	}
+
+	return ""
+}
+
+// Load finds and loads the config file, falling back to defaults when no
+// file was given explicitly and none can be loaded
+func Load(configFile string) (*Config, error) {
+	cfg, err := LoadConfig(FindConfigFile(configFile))
+	if err != nil {
+		if configFile != "" {
+			// Only return error if user explicitly specified a config file
+			return nil, fmt.Errorf("error loading config file: %w", err)
+		}
+		// Otherwise, use defaults
+		cfg = &Config{}
+		SetDefaults(cfg)
+	}
+	return cfg, nil
+}
+
 // LoadConfig loads configuration from file and environment variables
 func LoadConfig(configPath string) (*Config, error) {
 	v := viper.New()
@@ -64,6 +174,21 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Prompt template files live alongside the config file
+	if configPath != "" {
+		for i, rule := range config.Crawler.AI.Prompts {
+			if rule.File != "" && !filepath.IsAbs(rule.File) {
+				config.Crawler.AI.Prompts[i].File = filepath.Join(filepath.Dir(configPath), rule.File)
+			}
+		}
+	}
+
+	// So does a sitemap file read for queue priorities
+	if sitemap := config.Crawler.Queue.Sitemap; configPath != "" && sitemap != "" && !filepath.IsAbs(sitemap) &&
+		!strings.HasPrefix(sitemap, "http://") && !strings.HasPrefix(sitemap, "https://") {
+		config.Crawler.Queue.Sitemap = filepath.Join(filepath.Dir(configPath), sitemap)
+	}
+
 	return &config, nil
 }
 
@@ -74,9 +199,31 @@ func SetDefaults(cfg *Config) {
 	cfg.Crawler.Format = "markdown"
 	cfg.Crawler.OutputDir = "output"
 	cfg.Crawler.Parallelism = 4
+	cfg.Crawler.Compression = ""
+	cfg.Crawler.Relink = false
+	cfg.Crawler.Queue.Strategy = "bfs"
+	cfg.Crawler.Queue.Sitemap = ""
+	cfg.Crawler.Queue.InboundWeight = 0.1
+	cfg.Crawler.Index.Enabled = true
+	cfg.Crawler.Index.HTML = false
+	cfg.Crawler.Assets.Enabled = false
+	cfg.Crawler.Assets.Attachments = false
+	cfg.Crawler.Assets.AttachmentExts = []string{
+		"pdf", "doc", "docx", "xls", "xlsx",
+		"ppt", "pptx", "zip",
+	}
 	cfg.Crawler.AI.Enabled = false
-	cfg.Crawler.AI.Endpoint = "https://api.openai.com/v1"
-	cfg.Crawler.AI.Model = "gpt-3.5-turbo"
+	cfg.Crawler.AI.Provider = "openai"
+	cfg.Crawler.AI.Endpoint = ""
+	cfg.Crawler.AI.Model = ""
+	cfg.Crawler.AI.EmbeddingModel = ""
+	cfg.Crawler.AI.MaxRequestTokens = 12000
+	cfg.Crawler.AI.Mode = "summarize"
+	cfg.Crawler.AI.Tagging.Enabled = false
+	cfg.Crawler.AI.Translation.Language = ""
+	cfg.Crawler.AI.Budget = 0
+	cfg.Crawler.AI.RequestsPerMinute = 12
+	cfg.Crawler.AI.TokensPerMinute = 0
 	cfg.Crawler.AI.SystemPrompt = `You are an intelligent assistant specialized in processing and extracting relevant information from web-scraped markdown or text documents. Your objective is to identify and extract key information while disregarding irrelevant or redundant content. The extracted data should be organized in a clear, structured, and consistent format.
 
 **Instructions:**
@@ -148,9 +295,32 @@ func setViperDefaults(v *viper.Viper) {
 	v.SetDefault("crawler.format", "markdown")
 	v.SetDefault("crawler.output_dir", "output")
 	v.SetDefault("crawler.parallelism", 4)
+	v.SetDefault("crawler.compression", "")
+	v.SetDefault("crawler.relink", false)
+	v.SetDefault("crawler.queue.strategy", "bfs")
+	v.SetDefault("crawler.queue.sitemap", "")
+	v.SetDefault("crawler.queue.inbound_weight", 0.1)
+	v.SetDefault("crawler.index.enabled", true)
+	v.SetDefault("crawler.index.html", false)
+	v.SetDefault("crawler.assets.enabled", false)
+	v.SetDefault("crawler.assets.attachments", false)
+	v.SetDefault("crawler.assets.attachment_extensions", []string{
+		"pdf", "doc", "docx", "xls", "xlsx",
+		"ppt", "pptx", "zip",
+	})
 	v.SetDefault("crawler.ai.enabled", false)
-	v.SetDefault("crawler.ai.endpoint", "https://api.openai.com/v1")
-	v.SetDefault("crawler.ai.model", "gpt-3.5-turbo")
+	v.SetDefault("crawler.ai.provider", "openai")
+	v.SetDefault("crawler.ai.endpoint", "")
+	v.SetDefault("crawler.ai.model", "")
+	v.SetDefault("crawler.ai.embedding_model", "")
+	v.SetDefault("crawler.ai.max_request_tokens", 12000)
+	v.SetDefault("crawler.ai.mode", "summarize")
+	v.SetDefault("crawler.ai.tagging.enabled", false)
+	v.SetDefault("crawler.ai.tagging.taxonomy", []string{})
+	v.SetDefault("crawler.ai.translation.language", "")
+	v.SetDefault("crawler.ai.budget", 0)
+	v.SetDefault("crawler.ai.requests_per_minute", 12)
+	v.SetDefault("crawler.ai.tokens_per_minute", 0)
 	v.SetDefault("crawler.ai.system_prompt", `You are an intelligent assistant specialized in processing and extracting relevant information from web-scraped markdown or text documents. Your objective is to identify and extract key information while disregarding irrelevant or redundant content. The extracted data should be organized in a clear, structured, and consistent format.
 
 **Instructions:**
@@ -238,12 +408,43 @@ func MergeWithFlags(cfg *Config, flags map[string]interface{}) {
 	if v, ok := flags["parallelism"].(int); ok && v != 0 {
 		cfg.Crawler.Parallelism = v
 	}
+	if v, ok := flags["compression"].(string); ok && v != "" {
+		cfg.Crawler.Compression = v
+	}
+
+	if v, ok := flags["relink"].(bool); ok && v {
+		cfg.Crawler.Relink = true
+	}
+
+	if v, ok := flags["strategy"].(string); ok && v != "" {
+		cfg.Crawler.Queue.Strategy = v
+	}
+	if v, ok := flags["queue-sitemap"].(string); ok && v != "" {
+		cfg.Crawler.Queue.Sitemap = v
+	}
+
+	if v, ok := flags["index-html"].(bool); ok && v {
+		cfg.Crawler.Index.HTML = true
+	}
+
+	// Handle asset settings
+	if assetSettings, ok := flags["assets"].(map[string]interface{}); ok {
+		if enabled, ok := assetSettings["enabled"].(bool); ok && enabled {
+			cfg.Crawler.Assets.Enabled = true
+		}
+		if attachments, ok := assetSettings["attachments"].(bool); ok && attachments {
+			cfg.Crawler.Assets.Attachments = true
+		}
+	}
 
 	// Handle AI settings
 	if aiSettings, ok := flags["ai"].(map[string]interface{}); ok {
 		if enabled, ok := aiSettings["enabled"].(bool); ok {
 			cfg.Crawler.AI.Enabled = enabled
 		}
+		if provider, ok := aiSettings["provider"].(string); ok && provider != "" {
+			cfg.Crawler.AI.Provider = provider
+		}
 		if endpoint, ok := aiSettings["endpoint"].(string); ok && endpoint != "" {
 			cfg.Crawler.AI.Endpoint = endpoint
 		}
@@ -253,9 +454,39 @@ func MergeWithFlags(cfg *Config, flags map[string]interface{}) {
 		if model, ok := aiSettings["model"].(string); ok && model != "" {
 			cfg.Crawler.AI.Model = model
 		}
+		if model, ok := aiSettings["embedding_model"].(string); ok && model != "" {
+			cfg.Crawler.AI.EmbeddingModel = model
+		}
 		if prompt, ok := aiSettings["system_prompt"].(string); ok && prompt != "" {
 			cfg.Crawler.AI.SystemPrompt = prompt
 		}
+		if maxTokens, ok := aiSettings["max_request_tokens"].(int); ok && maxTokens != 0 {
+			cfg.Crawler.AI.MaxRequestTokens = maxTokens
+		}
+		if mode, ok := aiSettings["mode"].(string); ok && mode != "" {
+			cfg.Crawler.AI.Mode = mode
+		}
+		if schemaFile, ok := aiSettings["schema_file"].(string); ok && schemaFile != "" {
+			cfg.Crawler.AI.Extract.SchemaFile = schemaFile
+		}
+		if budget, ok := aiSettings["budget"].(float64); ok && budget != 0 {
+			cfg.Crawler.AI.Budget = budget
+		}
+		if rpm, ok := aiSettings["requests_per_minute"].(int); ok && rpm != 0 {
+			cfg.Crawler.AI.RequestsPerMinute = rpm
+		}
+		if tpm, ok := aiSettings["tokens_per_minute"].(int); ok && tpm != 0 {
+			cfg.Crawler.AI.TokensPerMinute = tpm
+		}
+		if tagging, ok := aiSettings["tagging"].(bool); ok && tagging {
+			cfg.Crawler.AI.Tagging.Enabled = true
+		}
+		if taxonomy, ok := aiSettings["taxonomy"].([]string); ok && len(taxonomy) > 0 {
+			cfg.Crawler.AI.Tagging.Taxonomy = taxonomy
+		}
+		if language, ok := aiSettings["translate"].(string); ok && language != "" {
+			cfg.Crawler.AI.Translation.Language = language
+		}
 	}
 }
 
@@ -263,3 +494,25 @@ func MergeWithFlags(cfg *Config, flags map[string]interface{}) {
 func ParseRescanInterval(interval string) (time.Duration, error) {
 	return time.ParseDuration(interval)
 }
+
+// Snapshot returns the configuration as JSON for recording with a crawl run.
+// The AI API key and reader API header values are redacted, since they
+// usually hold credentials.
+func (c Config) Snapshot() (string, error) {
+	if c.Crawler.AI.APIKey != "" {
+		c.Crawler.AI.APIKey = "REDACTED"
+	}
+	if len(c.Crawler.ReaderAPI.Headers) > 0 {
+		headers := make(map[string]string, len(c.Crawler.ReaderAPI.Headers))
+		for name := range c.Crawler.ReaderAPI.Headers {
+			headers[name] = "REDACTED"
+		}
+		c.Crawler.ReaderAPI.Headers = headers
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("error encoding configuration: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,21 @@
+package crawler
+
+import (
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+// aiCache stores AI results in the crawl database
+type aiCache struct {
+	db *database.DB
+}
+
+// Get returns a cached AI result
+func (a aiCache) Get(key ai.CacheKey) (string, bool, error) {
+	return a.db.GetAICache(key.ContentHash, key.Model, key.PromptHash, key.Provider)
+}
+
+// Put stores an AI result
+func (a aiCache) Put(key ai.CacheKey, result string) error {
+	return a.db.PutAICache(key.ContentHash, key.Model, key.PromptHash, key.Provider, result)
+}
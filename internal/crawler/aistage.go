@@ -0,0 +1,504 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"stripper/internal/ai"
+	"stripper/internal/config"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+	"stripper/internal/tui"
+)
+
+// AIOptions configures AI processing of stored pages
+type AIOptions struct {
+	Enabled          bool
+	Provider         string
+	Endpoint         string
+	APIKey           string
+	Model            string
+	EmbeddingModel   string
+	SystemPrompt     string
+	MaxRequestTokens int
+	Mode             string // "summarize" (default), "extract" or "translate" (translation only)
+	SchemaFile       string // JSON Schema for extract mode
+	ExtractPrompt    string
+	Prompts          []PromptRule        // per-URL prompt templates, first match wins
+	Tagging          bool                // also classify pages with tags, content type and language
+	Taxonomy         []string            // allowed tags; free-form when empty
+	TranslateTo      string              // also translate pages into this language; none when empty
+	Refresh          bool                // bypass cached AI results
+	Prices           map[string]ai.Price // per million tokens, keyed by model
+	Budget           float64             // stop AI processing at this spend, 0 for no limit
+
+	RequestsPerMinute int // shared by all workers, 0 for no limit
+	TokensPerMinute   int // shared by all workers, 0 for no limit
+}
+
+// AIOptionsFromConfig builds AI options from the crawler.ai configuration
+func AIOptionsFromConfig(cfg *config.Config) AIOptions {
+	opts := AIOptions{
+		Enabled:          cfg.Crawler.AI.Enabled,
+		Provider:         cfg.Crawler.AI.Provider,
+		Endpoint:         cfg.Crawler.AI.Endpoint,
+		APIKey:           cfg.Crawler.AI.APIKey,
+		Model:            cfg.Crawler.AI.Model,
+		EmbeddingModel:   cfg.Crawler.AI.EmbeddingModel,
+		SystemPrompt:     cfg.Crawler.AI.SystemPrompt,
+		MaxRequestTokens: cfg.Crawler.AI.MaxRequestTokens,
+		Mode:             cfg.Crawler.AI.Mode,
+		SchemaFile:       cfg.Crawler.AI.Extract.SchemaFile,
+		ExtractPrompt:    cfg.Crawler.AI.Extract.Prompt,
+		Tagging:          cfg.Crawler.AI.Tagging.Enabled,
+		Taxonomy:         cfg.Crawler.AI.Tagging.Taxonomy,
+		TranslateTo:      cfg.Crawler.AI.Translation.Language,
+		Budget:           cfg.Crawler.AI.Budget,
+
+		RequestsPerMinute: cfg.Crawler.AI.RequestsPerMinute,
+		TokensPerMinute:   cfg.Crawler.AI.TokensPerMinute,
+	}
+
+	// The model is resolved here rather than by the AI client, since it
+	// also selects prices and is recorded with usage and cached results
+	if opts.Model == "" {
+		opts.Model = ai.DefaultModel(opts.Provider)
+	}
+
+	for _, p := range cfg.Crawler.AI.Prompts {
+		opts.Prompts = append(opts.Prompts, PromptRule{
+			Pattern:  p.Pattern,
+			Regex:    p.Regex,
+			Template: p.Template,
+			File:     p.File,
+		})
+	}
+
+	opts.Prices = make(map[string]ai.Price, len(cfg.Crawler.AI.Prices))
+	for _, p := range cfg.Crawler.AI.Prices {
+		opts.Prices[p.Model] = ai.Price{Prompt: p.Prompt, Completion: p.Completion}
+	}
+	return opts
+}
+
+// aiStage runs the AI over pages that have already been stored. Pages are
+// processed in the background and their progress is tracked in the ai_status
+// column of the links table, so an interrupted stage can be resumed later.
+type aiStage struct {
+	client        *ai.Client
+	db            *database.DB
+	outputDir     string
+	seeds         []string // crawl base URLs, used to name AI output files
+	mode          string
+	model         string
+	systemPrompt  string
+	extractPrompt string
+	prompts       []promptTemplate
+	schema        *ai.Schema
+	tagging       bool
+	taxonomy      []string
+	translateTo   string
+	price         ai.Price
+	budget        float64
+	run           string // start time of this run
+	runID         int64  // id of the crawl run, 0 outside a crawl
+
+	// onUsage, when set, receives the running totals after each page
+	onUsage func(tui.UsageMsg)
+
+	store   storage.Storage
+	format  string
+	jobs    chan *aiJob
+	pending sync.WaitGroup
+	retries retryQueue
+
+	usageMu         sync.Mutex
+	usage           ai.Usage
+	cost            float64
+	reserved        float64 // estimated cost of the pages being processed
+	budgetExhausted bool
+	report          AIReport
+}
+
+// AIReport summarizes an AI pass
+type AIReport struct {
+	Completed int // pages with new AI output
+	Failed    int // pages the AI failed on
+	Skipped   int // pages left pending because the budget ran out
+	Usage     ai.Usage
+	Cost      float64
+}
+
+// newAIClient creates an AI client that caches results in the crawl database
+func newAIClient(db *database.DB, opts AIOptions) (*ai.Client, error) {
+	client, err := ai.New(ai.Options{
+		Provider:       opts.Provider,
+		Endpoint:       opts.Endpoint,
+		APIKey:         opts.APIKey,
+		Model:          opts.Model,
+		EmbeddingModel: opts.EmbeddingModel,
+
+		MaxRequestTokens: opts.MaxRequestTokens,
+		Cache:            aiCache{db: db},
+		Refresh:          opts.Refresh,
+
+		RequestsPerMinute: opts.RequestsPerMinute,
+		TokensPerMinute:   opts.TokensPerMinute,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AI client: %w", err)
+	}
+	return client, nil
+}
+
+// newAIStage creates the AI client, validates the AI mode and starts
+// parallelism workers reading pages back from store
+func newAIStage(db *database.DB, store storage.Storage, format string, outputDir string, seeds []string, parallelism int, opts AIOptions) (*aiStage, error) {
+	client, err := newAIClient(db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	s := &aiStage{
+		client:        client,
+		db:            db,
+		outputDir:     outputDir,
+		seeds:         seeds,
+		mode:          opts.Mode,
+		model:         opts.Model,
+		systemPrompt:  opts.SystemPrompt,
+		extractPrompt: opts.ExtractPrompt,
+		tagging:       opts.Tagging,
+		taxonomy:      opts.Taxonomy,
+		translateTo:   opts.TranslateTo,
+		budget:        opts.Budget,
+		run:           time.Now().UTC().Format(time.RFC3339),
+		store:         store,
+		format:        format,
+		jobs:          make(chan *aiJob, aiQueueSize),
+	}
+
+	if s.prompts, err = compilePrompts(opts.Prompts); err != nil {
+		return nil, err
+	}
+
+	price, ok := opts.Prices[opts.Model]
+	if s.budget > 0 && !ok {
+		return nil, fmt.Errorf("an AI budget requires a price for model %s in crawler.ai.prices", opts.Model)
+	}
+	s.price = price
+
+	switch s.mode {
+	case "", "summarize":
+		s.mode = "summarize"
+	case "extract":
+		if opts.SchemaFile == "" {
+			return nil, fmt.Errorf("a JSON schema file is required for AI extract mode")
+		}
+		data, err := os.ReadFile(opts.SchemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON schema: %w", err)
+		}
+		if s.schema, err = ai.ParseSchema(data); err != nil {
+			return nil, err
+		}
+	case "translate":
+		if s.translateTo == "" {
+			return nil, fmt.Errorf("AI translate mode requires a target language (crawler.ai.translation.language)")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported AI mode: %s (use summarize, extract or translate)", s.mode)
+	}
+
+	for i := 0; i < parallelism; i++ {
+		go s.work()
+	}
+	return s, nil
+}
+
+// submit queues a stored page for AI processing. Only the link is queued;
+// its content is read back from storage when a worker picks it up. submit
+// blocks while the queue is full. Pages submitted after the budget ran out
+// stay pending for a later run.
+func (s *aiStage) submit(link database.Link) {
+	s.setStatus(link.URL, "pending", nil)
+	if s.overBudget() {
+		debugf("Skipping AI for %s: budget reached", link.URL)
+		s.finish(link.URL, "pending", nil)
+		return
+	}
+
+	s.pending.Add(1)
+	s.jobs <- &aiJob{link: link}
+}
+
+// work runs queued jobs until the queue is closed
+func (s *aiStage) work() {
+	for job := range s.jobs {
+		s.process(job)
+		s.pending.Done()
+	}
+}
+
+// process loads the stored content of a page and attempts its job. The content
+// is only held while the attempt runs, not while a retry waits. The estimated
+// cost of the attempt is reserved against the budget until it is done; a page
+// that does not fit stays pending.
+func (s *aiStage) process(job *aiJob) {
+	content, err := s.store.Load(job.link.URL, s.format)
+	if err != nil {
+		s.recordUsage(job.link.URL, job.usage)
+		s.finish(job.link.URL, "failed", fmt.Errorf("error loading stored content: %w", err))
+		return
+	}
+
+	job.content = storage.StripMetadata(content)
+	if !s.reserve(job) {
+		debugf("Skipping AI for %s: not enough budget left", job.link.URL)
+		s.recordUsage(job.link.URL, job.usage)
+		s.finish(job.link.URL, "pending", nil)
+		job.content = ""
+		return
+	}
+
+	debugf("Attempting AI summary for %s", job.link.URL)
+	s.attempt(job)
+	s.release(job)
+	job.content = ""
+}
+
+// wait blocks until every submitted page, including retries, is done and
+// returns the report of the run. No pages can be submitted afterwards.
+func (s *aiStage) wait() AIReport {
+	s.pending.Wait()
+	s.retries.wait()
+	close(s.jobs)
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	report := s.report
+	report.Usage = s.usage
+	report.Cost = s.cost
+	return report
+}
+
+// attempt runs the AI steps on a page and stores the results: the mode's
+// output, then the classification and translation when enabled. Attempts
+// run on the AI workers, never on the fetch workers. Retryable errors put the
+// page on the retry queue; steps that already succeeded are served from the
+// cache on the next attempt.
+func (s *aiStage) attempt(job *aiJob) {
+	var output string
+	if s.mode != "translate" {
+		prompt, err := s.promptFor(job.link, job.content)
+		if err != nil {
+			s.finish(job.link.URL, "failed", err)
+			return
+		}
+
+		var usage ai.Usage
+		output, usage, err = s.runAI(job.content, prompt)
+		job.usage.Add(usage)
+		if err != nil {
+			s.fail(job, err)
+			return
+		}
+		debugf("Successfully generated AI summary for %s (%d chars)", job.link.URL, len(output))
+	}
+
+	var cls *ai.Classification
+	if s.tagging {
+		var usage ai.Usage
+		var err error
+		cls, usage, err = s.client.Classify(job.content, s.taxonomy)
+		job.usage.Add(usage)
+		if err != nil {
+			s.fail(job, err)
+			return
+		}
+	}
+
+	if s.translateTo != "" {
+		if err := s.translate(job); err != nil {
+			s.fail(job, err)
+			return
+		}
+	}
+
+	if err := s.saveOutput(job.link.URL, output, cls); err != nil {
+		s.recordUsage(job.link.URL, job.usage)
+		s.finish(job.link.URL, "failed", err)
+		return
+	}
+	s.recordUsage(job.link.URL, job.usage)
+	s.finish(job.link.URL, "completed", nil)
+}
+
+// fail schedules a retry for retryable errors and otherwise marks the page
+// as failed. When the provider sent a Retry-After, the client has already
+// paused its limiter for that long, so the retry is queued right away and
+// waits there; otherwise it backs off exponentially.
+func (s *aiStage) fail(job *aiJob, err error) {
+	if ai.IsRetryable(err) && job.attempts < maxAIRetries {
+		var wait time.Duration
+		if ai.RetryAfter(err) == 0 {
+			wait = aiBackoff(job.attempts)
+		}
+		job.attempts++
+		debugf("Retryable AI error (%v), retry %d for %s in %v", err, job.attempts, job.link.URL, wait)
+		s.setStatus(job.link.URL, "pending", err)
+		s.pending.Add(1)
+		s.retries.schedule(wait, func() { s.retry(job) })
+		return
+	}
+
+	debugf("Error processing %s with AI: %v", job.link.URL, err)
+	s.recordUsage(job.link.URL, job.usage)
+	s.finish(job.link.URL, "failed", err)
+}
+
+// finish records the final AI status of a page for this run
+func (s *aiStage) finish(pageURL string, status string, err error) {
+	s.setStatus(pageURL, status, err)
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	switch status {
+	case "completed":
+		s.report.Completed++
+	case "failed":
+		s.report.Failed++
+	default:
+		s.report.Skipped++
+	}
+}
+
+// setStatus records the AI status of a page. Translate mode tracks its
+// progress in the translations table only, so the status of the page's
+// summary is left alone.
+func (s *aiStage) setStatus(pageURL string, status string, err error) {
+	if s.mode == "translate" {
+		return
+	}
+	s.db.UpdateAIStatus(pageURL, status, err)
+}
+
+// retry puts a page back on the queue once its delay is over, unless the
+// budget ran out in the meantime, in which case the page stays pending
+func (s *aiStage) retry(job *aiJob) {
+	if s.overBudget() {
+		s.recordUsage(job.link.URL, job.usage)
+		s.finish(job.link.URL, "pending", nil)
+		s.pending.Done()
+		return
+	}
+	s.jobs <- job
+}
+
+// runAI applies the configured AI mode to page content using the prompt
+// selected for the page
+func (s *aiStage) runAI(content string, prompt string) (string, ai.Usage, error) {
+	if s.mode == "extract" {
+		return s.client.Extract(content, s.schema, prompt)
+	}
+	return s.client.Summarize(content, prompt)
+}
+
+// schemaHash identifies the extraction schema so results produced with
+// different schemas can be told apart
+func (s *aiStage) schemaHash() string {
+	sum := sha256.Sum256(s.schema.Raw())
+	return hex.EncodeToString(sum[:8])
+}
+
+// saveOutput writes AI output to the ai directory, recording extractions and
+// classifications in the database as well. Summaries of classified pages
+// start with YAML front matter holding the classification. Translate mode
+// has no output of its own.
+func (s *aiStage) saveOutput(pageURL string, output string, cls *ai.Classification) error {
+	if cls != nil {
+		if err := s.db.SaveClassification(database.Classification{
+			URL:         pageURL,
+			Tags:        cls.Tags,
+			ContentType: cls.ContentType,
+			Language:    cls.Language,
+			Model:       s.model,
+		}); err != nil {
+			return fmt.Errorf("error recording classification: %w", err)
+		}
+		if s.mode == "summarize" {
+			output = frontMatter(cls) + output
+		}
+	}
+	if s.mode == "translate" {
+		return nil
+	}
+
+	// Create AI output directory
+	aiOutputDir := path.Join(s.outputDir, "ai")
+	if err := os.MkdirAll(aiOutputDir, 0755); err != nil {
+		return fmt.Errorf("error creating AI output directory: %w", err)
+	}
+
+	// Create flat file name for AI output
+	fileName := AISummaryFilename(seedFor(s.seeds, pageURL), pageURL)
+	if s.mode == "extract" {
+		fileName = strings.TrimSuffix(fileName, ".md") + ".json"
+		if err := s.db.SaveExtraction(database.Extraction{
+			URL:        pageURL,
+			Data:       output,
+			SchemaHash: s.schemaHash(),
+			Model:      s.model,
+		}); err != nil {
+			debugf("Error recording extraction for %s: %v", pageURL, err)
+		}
+	}
+
+	// Save directly to ai directory
+	debugf("Saving AI summary to: ai/%s (from URL: %s)", fileName, pageURL)
+	if err := os.WriteFile(path.Join(aiOutputDir, fileName), []byte(output), 0644); err != nil {
+		return fmt.Errorf("error saving AI summary: %w", err)
+	}
+	return nil
+}
+
+// seedFor returns the longest seed URL that prefixes pageURL, since AI
+// output files are named relative to the base URL of the crawl
+func seedFor(seeds []string, pageURL string) string {
+	base := ""
+	for _, seed := range seeds {
+		if strings.HasPrefix(pageURL, seed) && len(seed) > len(base) {
+			base = seed
+		}
+	}
+	return base
+}
+
+// frontMatter renders a classification as YAML front matter. Tags use the
+// JSON array syntax, which is valid YAML and quotes any special characters.
+func frontMatter(cls *ai.Classification) string {
+	tags, _ := json.Marshal(cls.Tags)
+	return fmt.Sprintf("---\ntags: %s\ncontent_type: %s\nlanguage: %s\n---\n\n", tags, cls.ContentType, cls.Language)
+}
+
+// stripFrontMatter removes YAML front matter from the start of content
+func stripFrontMatter(content string) string {
+	if !strings.HasPrefix(content, "---\n") {
+		return content
+	}
+	if idx := strings.Index(content[4:], "\n---\n"); idx != -1 {
+		return strings.TrimLeft(content[4+idx+5:], "\n")
+	}
+	return content
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+// DefaultAskSources is the number of chunks retrieved for a question when
+// AskOptions.Sources is not set
+const DefaultAskSources = 6
+
+// AskOptions configures answering a question from an archive
+type AskOptions struct {
+	Sources int // chunks retrieved as context
+	AI      AIOptions
+}
+
+// Answer is the response to a question together with the chunks it was
+// based on; citations [n] in Text refer to Sources[n-1]
+type Answer struct {
+	Text    string
+	Sources []SearchResult
+	Usage   ai.Usage
+	Cost    float64
+}
+
+// Ask retrieves the chunks closest to the question from the embedding index,
+// or from the full-text index when the archive has not been embedded, and
+// has the AI answer it from them, citing the pages it used
+func Ask(db *database.DB, opts AskOptions, question string) (*Answer, error) {
+	limit := opts.Sources
+	if limit <= 0 {
+		limit = DefaultAskSources
+	}
+
+	client, err := newAIClient(db, opts.AI)
+	if err != nil {
+		return nil, err
+	}
+	meter, err := newUsageMeter(db, client, opts.AI, opts.AI.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := semanticSearch(db, client, meter, question, limit)
+	if errors.Is(err, ErrNoEmbeddings) {
+		results, err = fullTextSources(db, question, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no pages in the archive match the question")
+	}
+
+	sources := make([]ai.Source, len(results))
+	estimated := ai.EstimateTokens(question)
+	for i, r := range results {
+		sources[i] = ai.Source{URL: r.URL, Heading: r.Heading, Content: r.Content}
+		estimated += ai.EstimateTokens(r.Content)
+	}
+	reserved, err := meter.reserve(opts.AI.Model, estimated)
+	if err != nil {
+		return nil, err
+	}
+
+	text, used, usage, err := client.Answer(question, sources)
+	meter.record("", opts.AI.Model, usage, reserved)
+	answer := &Answer{
+		Text:    text,
+		Sources: results[:used],
+	}
+	answer.Usage, answer.Cost = meter.totals()
+	if err != nil {
+		return answer, fmt.Errorf("error answering question: %w", err)
+	}
+	return answer, nil
+}
+
+// fullTextSources finds the pages matching any word of the question and
+// picks from each the chunk that mentions the question's words most often
+func fullTextSources(db *database.DB, question string, limit int) ([]SearchResult, error) {
+	hits, err := db.Search(database.SearchQuery{Query: question, Limit: limit, AnyWord: true})
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		body, err := db.GetPageBody(hit.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s from the search index: %w", hit.URL, err)
+		}
+
+		var best docChunk
+		bestCount := -1
+		for _, chunk := range chunkWithHeadings(body, DefaultChunkTokens) {
+			text := strings.ToLower(chunk.text)
+			count := 0
+			for _, word := range words {
+				count += strings.Count(text, word)
+			}
+			if count > bestCount {
+				best, bestCount = chunk, count
+			}
+		}
+		if bestCount < 0 {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			URL:     hit.URL,
+			Heading: best.heading,
+			Content: best.text,
+			Score:   hit.Score,
+		})
+	}
+	return results, nil
+}
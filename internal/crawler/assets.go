@@ -0,0 +1,188 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"stripper/internal/database"
+)
+
+// maxAssetSize caps the size of a single downloaded asset
+const maxAssetSize = 50 << 20
+
+var (
+	// markdownRefPattern matches markdown links and images, capturing the
+	// leading "!" for images and the link target
+	markdownRefPattern = regexp.MustCompile(`(!?)\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+	// htmlRefPattern matches img and anchor tags, capturing the tag name and
+	// the src/href value
+	htmlRefPattern = regexp.MustCompile(`(?i)<(img|a)\s[^>]*?(?:src|href)\s*=\s*["']([^"']+)["']`)
+
+	imageExts = []string{"png", "jpg", "jpeg", "gif", "svg", "webp", "avif", "bmp", "ico"}
+)
+
+// rewriteRefs calls fn for every link target matched by pattern and replaces
+// the target with the returned value. The target is the last capture group of
+// the pattern and the first group is passed to fn as the reference kind.
+func rewriteRefs(content string, pattern *regexp.Regexp, fn func(kind, target string) string) string {
+	matches := pattern.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		kindStart, kindEnd := m[2], m[3]
+		targetStart, targetEnd := m[len(m)-2], m[len(m)-1]
+
+		kind := ""
+		if kindStart >= 0 {
+			kind = content[kindStart:kindEnd]
+		}
+		target := content[targetStart:targetEnd]
+
+		b.WriteString(content[last:targetStart])
+		b.WriteString(fn(kind, target))
+		last = targetEnd
+	}
+	b.WriteString(content[last:])
+
+	return b.String()
+}
+
+// localizeAssets downloads the images (and optionally attachments) referenced
+// by a page and rewrites the references to point at the local copies
+func (c *Crawler) localizeAssets(pageURL string, content string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return content
+	}
+
+	localize := func(isImage bool, target string) string {
+		ref, err := base.Parse(target)
+		if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+			return target
+		}
+
+		if !isImage && !c.isAttachment(ref.Path) {
+			return target
+		}
+
+		localPath, err := c.downloadAsset(ref.String(), pageURL)
+		if err != nil {
+			debugf("Error downloading asset %s: %v", ref.String(), err)
+			return target
+		}
+		return localPath
+	}
+
+	switch c.format {
+	case "markdown":
+		return rewriteRefs(content, markdownRefPattern, func(kind, target string) string {
+			return localize(kind == "!" || hasExtension(target, imageExts), target)
+		})
+	case "html":
+		return rewriteRefs(content, htmlRefPattern, func(kind, target string) string {
+			return localize(strings.EqualFold(kind, "img"), target)
+		})
+	}
+
+	return content
+}
+
+// isAttachment checks whether a link path should be downloaded as an attachment
+func (c *Crawler) isAttachment(p string) bool {
+	return c.assetAttachments && hasExtension(p, c.attachmentExts)
+}
+
+// downloadAsset fetches an asset into the assets directory and returns its
+// path relative to the output directory. Assets are stored by content hash so
+// identical files referenced from different URLs are only kept once.
+func (c *Crawler) downloadAsset(assetURL string, pageURL string) (string, error) {
+	existing, err := c.db.GetAsset(assetURL)
+	if err != nil {
+		return "", fmt.Errorf("error looking up asset: %w", err)
+	}
+	if existing != nil {
+		if _, err := os.Stat(filepath.Join(c.outputDir, existing.Path)); err == nil {
+			return existing.Path, nil
+		}
+	}
+
+	debugf("Downloading asset %s (from %s)", assetURL, pageURL)
+
+	resp, err := c.client.Get(assetURL)
+	if err != nil {
+		return "", fmt.Errorf("error fetching asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("asset request failed with status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return "", fmt.Errorf("error reading asset: %w", err)
+	}
+	if len(data) > maxAssetSize {
+		return "", fmt.Errorf("asset exceeds %d bytes", maxAssetSize)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	contentType := resp.Header.Get("Content-Type")
+
+	localPath := path.Join("assets", hash[:2], hash+assetExt(assetURL, contentType))
+	fullPath := filepath.Join(c.outputDir, filepath.FromSlash(localPath))
+
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return "", fmt.Errorf("error creating asset directory: %w", err)
+		}
+		if err := os.WriteFile(fullPath, data, 0644); err != nil {
+			return "", fmt.Errorf("error saving asset: %w", err)
+		}
+	}
+
+	if err := c.db.SaveAsset(database.Asset{
+		URL:         assetURL,
+		PageURL:     pageURL,
+		Hash:        hash,
+		Path:        localPath,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}); err != nil {
+		return "", fmt.Errorf("error recording asset: %w", err)
+	}
+
+	return localPath, nil
+}
+
+// assetExt picks a file extension for an asset from its URL, falling back to
+// the response content type
+func assetExt(assetURL string, contentType string) string {
+	if u, err := url.Parse(assetURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); ext != "" && len(ext) <= 6 {
+			return ext
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ""
+}
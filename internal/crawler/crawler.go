@@ -1,17 +1,16 @@
 package crawler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"strings"
 	"sync"
 	"time"
 
-	"stripper/internal/ai"
 	"stripper/internal/database"
 	"stripper/internal/storage"
 	"stripper/internal/tui"
@@ -35,9 +34,20 @@ type Crawler struct {
 	rescanInterval time.Duration
 	readerAPIURL   string
 	parallelism    int
+	queue          *queue
 	aiEnabled      bool
-	aiClient       *ai.Client
-	systemPrompt   string
+	ai             *aiStage
+
+	assetsEnabled    bool
+	assetAttachments bool
+	attachmentExts   []string
+	relink           bool
+	indexEnabled     bool
+	indexHTML        bool
+
+	config string // configuration snapshot recorded with the run
+	runMu  sync.Mutex
+	run    database.Run
 }
 
 // Options configures the crawler behavior
@@ -51,13 +61,20 @@ type Options struct {
 	RescanInterval time.Duration
 	ReaderAPIURL   string
 	Parallelism    int
-	AI             struct {
-		Enabled      bool
-		Endpoint     string
-		APIKey       string
-		Model        string
-		SystemPrompt string
+	Compression    string
+	Relink         bool
+	Queue          QueueOptions
+	Index          struct {
+		Enabled bool
+		HTML    bool
 	}
+	Assets struct {
+		Enabled        bool
+		Attachments    bool
+		AttachmentExts []string
+	}
+	AI     AIOptions
+	Config string // JSON snapshot of the effective configuration, recorded with the run
 }
 
 // New creates a new Crawler instance
@@ -67,8 +84,22 @@ func New(opts Options) (*Crawler, error) {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
+	// Validate the queue strategy and read the priority sources
+	q, err := newQueue(opts.Queue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relinked pages point at each other's files, which browsers cannot
+	// follow once compressed
+	if opts.Relink && opts.Compression != "" {
+		return nil, fmt.Errorf("--relink cannot be combined with compression: %w", ErrCompressedPages)
+	}
+
 	// Initialize storage
-	store, err := storage.NewFileStorage(opts.OutputDir)
+	store, err := storage.NewFileStorage(opts.OutputDir, storage.Options{
+		Compression: opts.Compression,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
@@ -80,6 +111,13 @@ func New(opts Options) (*Crawler, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// The search index of a database created by a build with FTS5 cannot be
+	// updated by one without it and would silently go stale
+	if db.SearchModule() == "" {
+		db.Close()
+		return nil, fmt.Errorf("cannot crawl into %s: %w", opts.OutputDir, database.ErrSearchUnavailable)
+	}
+
 	// Set default Reader API URL if not provided
 	readerAPIURL := opts.ReaderAPIURL
 	if readerAPIURL == "" {
@@ -100,30 +138,40 @@ func New(opts Options) (*Crawler, error) {
 		rescanInterval: opts.RescanInterval,
 		readerAPIURL:   readerAPIURL,
 		parallelism:    opts.Parallelism,
+		queue:          q,
 		aiEnabled:      opts.AI.Enabled,
-		systemPrompt:   opts.AI.SystemPrompt,
-	}
 
-	// Initialize AI client if enabled
-	if c.aiEnabled {
-		if opts.AI.APIKey == "" {
-			return nil, fmt.Errorf("AI API key is required when AI is enabled")
-		}
-		c.aiClient = ai.New(ai.Options{
-			Endpoint: opts.AI.Endpoint,
-			APIKey:   opts.AI.APIKey,
-			Model:    opts.AI.Model,
-		})
+		assetsEnabled:    opts.Assets.Enabled,
+		assetAttachments: opts.Assets.Attachments,
+		attachmentExts:   opts.Assets.AttachmentExts,
+		relink:           opts.Relink,
+		indexEnabled:     opts.Index.Enabled,
+		indexHTML:        opts.Index.HTML,
+
+		config: opts.Config,
 	}
 
 	// Initialize TUI
 	c.ui = tui.New(db)
 
+	// Initialize the AI stage if enabled
+	if c.aiEnabled {
+		c.ai, err = newAIStage(db, store, opts.Format, opts.OutputDir, []string{baseURL.String()}, opts.Parallelism, opts.AI)
+		if err != nil {
+			return nil, err
+		}
+		c.ai.onUsage = func(msg tui.UsageMsg) { c.ui.Send(msg) }
+	}
+
 	return c, nil
 }
 
 // Start begins the crawling process
 func (c *Crawler) Start() error {
+	if err := c.startRun(); err != nil {
+		return err
+	}
+
 	var wg sync.WaitGroup
 	errChan := make(chan error, 1)
 	doneChan := make(chan bool, 1)
@@ -155,6 +203,22 @@ func (c *Crawler) Start() error {
 			return
 		}
 
+		// Third phase: Point links between archived pages at the local files
+		if c.relink {
+			if err := c.relinkArchive(); err != nil {
+				errChan <- fmt.Errorf("error relinking pages: %w", err)
+				return
+			}
+		}
+
+		// Regenerate the table of contents for the output directory
+		if c.indexEnabled {
+			if err := c.writeIndex(); err != nil {
+				errChan <- fmt.Errorf("error writing index: %w", err)
+				return
+			}
+		}
+
 		doneChan <- true
 	}()
 
@@ -170,10 +234,12 @@ func (c *Crawler) Start() error {
 		case err := <-errChan:
 			c.ui.Quit()
 			wg.Wait()
+			c.finishRun(err)
 			return err
 		case <-doneChan:
 			c.ui.Quit()
 			wg.Wait()
+			c.finishRun(nil)
 			return nil
 		}
 	}
@@ -181,12 +247,15 @@ func (c *Crawler) Start() error {
 
 // collectLinks uses colly to find all links on the site
 func (c *Crawler) collectLinks() error {
-	// Create collector without depth limit since we'll handle it ourselves
+	// Create collector without depth limit since we'll handle it ourselves.
+	// Links and redirects are limited to the base URL's host by hand, so
+	// redirects to other hosts are recorded before they are refused.
 	collector := colly.NewCollector(
-		colly.AllowedDomains(c.baseURL.Host),
 		colly.Async(true),
 	)
 
+	c.trackEdges(collector, nil)
+
 	// Add rate limiting
 	collector.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
@@ -197,7 +266,7 @@ func (c *Crawler) collectLinks() error {
 	debugf("Starting link collection for %s with depth %d", c.baseURL.String(), c.depth)
 
 	// Queue the initial URL at depth 0
-	if err := c.db.QueueLink(c.baseURL.String(), 0); err != nil {
+	if err := c.queueLink(c.baseURL.String(), 0); err != nil {
 		return fmt.Errorf("failed to queue initial URL: %w", err)
 	}
 
@@ -217,7 +286,7 @@ func (c *Crawler) collectLinks() error {
 		}
 
 		// Only process links from the same domain as the base URL
-		if parsedLink.Host != c.baseURL.Host {
+		if !c.allowedHost(parsedLink) {
 			debugf("Skipping external domain: %s", link)
 			return
 		}
@@ -228,12 +297,14 @@ func (c *Crawler) collectLinks() error {
 			return
 		}
 
+		c.recordLink(e, link)
+
 		// Calculate depth based on the parent request
 		depth := e.Request.Depth + 1
 
 		// Only queue links if we haven't reached max depth
 		if depth <= c.depth {
-			if err := c.db.QueueLink(link, depth); err != nil {
+			if err := c.queueLink(link, depth); err != nil {
 				debugf("Error queueing link %s: %v", link, err)
 			} else {
 				debugf("Queued link: %s (depth: %d)", link, depth)
@@ -264,21 +335,13 @@ func (c *Crawler) collectLinks() error {
 // processLinks processes queued links using the Reader API
 func (c *Crawler) processLinks() error {
 	const (
-		batchSize      = 5 // Reduced batch size
-		delay          = 1 * time.Second
-		maxRetries     = 5                // Increased retries
-		aiRateLimit    = 5 * time.Second  // Increased delay between AI requests
-		backoffInitial = 5 * time.Second  // Increased initial backoff
-		backoffMax     = 60 * time.Second // Increased max backoff
+		batchSize = 5 // Reduced batch size
+		delay     = 1 * time.Second
 	)
 
-	// Create a rate limiter for AI requests
-	aiLimiter := time.NewTicker(aiRateLimit)
-	defer aiLimiter.Stop()
-
 	for {
 		// Get next batch of links
-		links, err := c.db.GetNextBatch(batchSize)
+		links, err := c.db.GetNextBatch(batchSize, c.queue.order)
 		if err != nil {
 			return fmt.Errorf("error getting next batch: %w", err)
 		}
@@ -304,19 +367,40 @@ func (c *Crawler) processLinks() error {
 
 				debugf("Processing link: %s (depth: %d)", link.URL, link.Depth)
 
-				// Always collect links from pages we visit to find new content
-				if err := c.collectLinksFromURL(link.URL, link.Depth); err != nil {
+				// Always collect links from pages we visit to find new content.
+				// Pages the site answers with an HTTP error are not fetched.
+				visit, err := c.collectLinksFromURL(link.URL, link.Depth)
+				if err != nil {
+					var statusErr *statusError
+					var offsiteErr *offsiteRedirectError
+					switch {
+					case errors.As(err, &statusErr):
+						c.recordFetch(visit, nil)
+						c.setLinkStatus(link.URL, "failed", err)
+						return
+					case errors.As(err, &offsiteErr):
+						c.setRedirected(link, offsiteErr.target, visit.redirects, offsiteErr)
+						return
+					}
 					debugf("Error collecting links from %s: %v", link.URL, err)
 				}
 
+				// Content of a redirecting URL is stored under its final
+				// URL, which is queued like any other link
+				if len(visit.redirects) > 0 && visit.finalURL != link.URL {
+					c.setRedirected(link, visit.finalURL, visit.redirects, nil)
+					return
+				}
+
 				// Check if we should recrawl content
 				shouldCrawl, err := c.db.ShouldRecrawl(link.URL, c.force, c.rescanInterval)
 				if err != nil {
-					c.db.UpdateLinkStatus(link.URL, "failed", err)
+					c.setLinkStatus(link.URL, "failed", err)
 					return
 				}
 
 				if !shouldCrawl {
+					c.countSkipped()
 					debugf("Skipping recent URL: %s (last crawled: %s)", link.URL, link.LastCrawled)
 					return
 				}
@@ -324,85 +408,27 @@ func (c *Crawler) processLinks() error {
 				debugf("Fetching content for URL: %s", link.URL)
 
 				// Fetch content using Reader API
+				readerStart := time.Now()
 				content, err := c.fetch(link.URL)
+				visit.readerLatency = time.Since(readerStart)
 				if err != nil {
-					c.db.UpdateLinkStatus(link.URL, "failed", err)
+					c.recordFetch(visit, nil)
+					c.setLinkStatus(link.URL, "failed", err)
 					errChan <- err
 					return
 				}
 
-				// Generate AI summary first if enabled
-				var aiSummary string
-				if c.aiEnabled && c.aiClient != nil {
-					debugf("Attempting AI summary for %s", link.URL)
-
-					// Wait for rate limiter
-					<-aiLimiter.C
-
-					// Try with exponential backoff
-					backoff := backoffInitial
-					for retries := 0; retries < maxRetries; retries++ {
-						summary, err := c.aiClient.Summarize(content, c.systemPrompt)
-						if err != nil {
-							if strings.Contains(err.Error(), "429") {
-								debugf("Rate limited, waiting %v before retry %d for %s", backoff, retries+1, link.URL)
-								time.Sleep(backoff)
-								backoff *= 2
-								if backoff > backoffMax {
-									backoff = backoffMax
-								}
-								continue
-							}
-							debugf("Error generating AI summary for %s: %v", link.URL, err)
-							break
-						}
-						debugf("Successfully generated AI summary for %s (%d chars)", link.URL, len(summary))
-						aiSummary = summary
-						break
-					}
-				} else {
-					debugf("Skipping AI summary for %s (enabled: %v, client: %v)", link.URL, c.aiEnabled, c.aiClient != nil)
-				}
-
-				// If AI is enabled but we failed to get a summary, mark as failed
-				if c.aiEnabled && c.aiClient != nil && aiSummary == "" {
-					c.db.UpdateLinkStatus(link.URL, "failed", fmt.Errorf("failed to generate AI summary"))
-					return
-				}
-
-				// Store original content
-				if err := c.storage.Save(link.URL, content, c.format); err != nil {
-					c.db.UpdateLinkStatus(link.URL, "failed", err)
+				// Content is always stored first; AI output is generated
+				// in the background from the content as stored, so results
+				// cached here are found again by stripper summarize
+				if err := c.finishPage(link, content, visit); err != nil {
 					errChan <- err
 					return
 				}
-
-				// Store AI summary if generated
-				if aiSummary != "" {
-					// Create AI output directory
-					aiOutputDir := path.Join(c.outputDir, "ai")
-					if err := os.MkdirAll(aiOutputDir, 0755); err != nil {
-						debugf("Error creating AI output directory: %v", err)
-						return
-					}
-
-					// Create flat file name for AI output
-					fileName := strings.TrimPrefix(link.URL, c.baseURL.String())
-					fileName = strings.ReplaceAll(fileName, "/", "_")
-					if fileName == "" {
-						fileName = "index"
-					}
-					fileName = strings.TrimSuffix(fileName, "_") + ".md"
-
-					// Save directly to ai directory
-					debugf("Saving AI summary to: ai/%s (from URL: %s)", fileName, link.URL)
-					if err := os.WriteFile(path.Join(aiOutputDir, fileName), []byte(aiSummary), 0644); err != nil {
-						debugf("Error saving AI summary for %s: %v", link.URL, err)
-					}
+				if c.ai != nil {
+					c.ai.submit(c.storedLink(link))
 				}
 
-				c.db.UpdateLinkStatus(link.URL, "completed", nil)
-
 				// Add delay between requests
 				time.Sleep(delay)
 			}(link)
@@ -420,14 +446,99 @@ func (c *Crawler) processLinks() error {
 		}
 	}
 
+	// Wait for AI processing of the stored pages, including retries
+	if c.ai != nil {
+		report := c.ai.wait()
+		debugf("AI processed %d pages (%d failed, %d left pending)", report.Completed, report.Failed, report.Skipped)
+	}
+
 	return nil
 }
 
-// collectLinksFromURL collects links from a specific URL
-func (c *Crawler) collectLinksFromURL(targetURL string, currentDepth int) error {
-	collector := colly.NewCollector(
-		colly.AllowedDomains(c.baseURL.Host),
-	)
+// storedLink returns the link as recorded in the database, so prompt
+// templates see the same values, such as the crawl time, as they do when
+// stripper summarize reads the link back
+func (c *Crawler) storedLink(link database.Link) database.Link {
+	stored, err := c.db.GetLink(link.URL)
+	if err != nil || stored == nil {
+		debugf("Error reading back %s: %v", link.URL, err)
+		return link
+	}
+	return *stored
+}
+
+// finishPage stores a fetched page, records the fetch and marks the link
+// completed
+func (c *Crawler) finishPage(link database.Link, content string, visit *pageVisit) error {
+	// Download referenced assets and point the content at local copies
+	if c.assetsEnabled {
+		content = c.localizeAssets(link.URL, content)
+	}
+
+	// Store original content
+	if err := c.storage.Save(link.URL, content, c.format); err != nil {
+		c.setLinkStatus(link.URL, "failed", err)
+		return err
+	}
+	doc := c.indexPage(link.URL, content)
+	c.recordFetch(visit, &doc)
+
+	c.setLinkStatus(link.URL, "completed", nil)
+	c.countFetched(len(content))
+	return nil
+}
+
+// aiSummaryFilename returns the flat file name, relative to the ai directory,
+// used for the AI summary of a URL
+func (c *Crawler) aiSummaryFilename(pageURL string) string {
+	return AISummaryFilename(c.baseURL.String(), pageURL)
+}
+
+// AISummaryFilename returns the flat file name, relative to the ai directory,
+// used for the AI summary of a page crawled from the given base URL
+func AISummaryFilename(baseURL string, pageURL string) string {
+	fileName := strings.TrimPrefix(pageURL, baseURL)
+	fileName = strings.ReplaceAll(fileName, "/", "_")
+	if fileName == "" {
+		fileName = "index"
+	}
+	return strings.TrimSuffix(fileName, "_") + ".md"
+}
+
+// relinkArchive rewrites links between stored pages to local relative paths
+func (c *Crawler) relinkArchive() error {
+	store, ok := c.storage.(*storage.FileStorage)
+	if !ok {
+		return fmt.Errorf("relinking requires file storage")
+	}
+
+	report, err := Relink(c.db, store, RelinkOptions{Format: c.format, Ignore: c.ignore})
+	if err != nil {
+		return err
+	}
+
+	debugf("Relinked %d links in %d of %d pages (%d dangling)", report.Rewritten, report.Updated, report.Pages, len(report.Dangling))
+	return nil
+}
+
+// pageVisit describes the request made to the site for a page
+type pageVisit struct {
+	url           string
+	finalURL      string // URL the page was loaded from after redirects
+	redirects     []database.Redirect
+	status        int
+	contentType   string
+	size          int64
+	duration      time.Duration
+	readerLatency time.Duration
+}
+
+// collectLinksFromURL collects links from a specific URL and describes the
+// response, including the redirects that led to it
+func (c *Crawler) collectLinksFromURL(targetURL string, currentDepth int) (*pageVisit, error) {
+	visit := &pageVisit{url: targetURL, finalURL: targetURL}
+	collector := colly.NewCollector()
+	c.trackEdges(collector, &visit.redirects)
 
 	collector.OnHTML("a[href]", func(e *colly.HTMLElement) {
 		link := e.Request.AbsoluteURL(e.Attr("href"))
@@ -436,7 +547,7 @@ func (c *Crawler) collectLinksFromURL(targetURL string, currentDepth int) error
 		}
 
 		parsedLink, err := url.Parse(link)
-		if err != nil || parsedLink.Host != c.baseURL.Host {
+		if err != nil || !c.allowedHost(parsedLink) {
 			return
 		}
 
@@ -444,9 +555,11 @@ func (c *Crawler) collectLinksFromURL(targetURL string, currentDepth int) error
 			return
 		}
 
+		c.recordLink(e, link)
+
 		depth := currentDepth + 1
 		if depth <= c.depth {
-			if err := c.db.QueueLink(link, depth); err != nil {
+			if err := c.queueLink(link, depth); err != nil {
 				debugf("Error queueing new link %s: %v", link, err)
 			} else {
 				debugf("Queued new link: %s (depth: %d)", link, depth)
@@ -454,7 +567,29 @@ func (c *Crawler) collectLinksFromURL(targetURL string, currentDepth int) error
 		}
 	})
 
-	return collector.Visit(targetURL)
+	describe := func(r *colly.Response) {
+		visit.finalURL = r.Request.URL.String()
+		visit.status = r.StatusCode
+		visit.size = int64(len(r.Body))
+		if r.Headers != nil {
+			visit.contentType = r.Headers.Get("Content-Type")
+		}
+	}
+	collector.OnResponse(describe)
+	collector.OnError(func(r *colly.Response, err error) {
+		describe(r)
+	})
+
+	start := time.Now()
+	err := collector.Visit(targetURL)
+	visit.duration = time.Since(start)
+	if err != nil {
+		if visit.status >= 400 {
+			return visit, &statusError{code: visit.status}
+		}
+		return visit, err
+	}
+	return visit, nil
 }
 
 // fetch retrieves content from a URL using the Reader API
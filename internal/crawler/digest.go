@@ -0,0 +1,234 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// DefaultDigestLinks is the number of key links listed per section when
+// DigestOptions.Links is not set
+const DefaultDigestLinks = 5
+
+const (
+	// sectionDigestPrompt turns page summaries into a section overview
+	sectionDigestPrompt = `You write the overview of one section of a website. The input consists of summaries of the section's pages, each starting with its title and URL and separated by "---". In one or two short paragraphs, describe what the section covers and what a reader can find there. Do not list every page and do not invent information.`
+
+	// siteDigestPrompt turns section overviews into a site overview
+	siteDigestPrompt = `You write the overview of a website. The input consists of overviews of the site's sections, each starting with the section name and separated by "---". In two or three short paragraphs, describe the purpose of the site, who it is for and its main areas. Do not invent information.`
+
+	// changesDigestPrompt describes added and updated pages
+	changesDigestPrompt = `You describe what changed on a website. The input consists of summaries of pages that were added or updated, each starting with its title, URL and whether it is new or updated, separated by "---". Write a short markdown bullet list of the notable changes a regular reader should know about. Do not invent information.`
+)
+
+// DigestOptions configures the site digest
+type DigestOptions struct {
+	OutputDir string    // crawl output directory
+	Dest      string    // file the digest is written to
+	Format    string    // format of the stored pages
+	Title     string    // site title, derived from the root page when empty
+	Links     int       // key links listed per section
+	Since     time.Time // when set, describe the pages changed since then
+	AI        AIOptions
+}
+
+// DigestReport summarizes a generated digest
+type DigestReport struct {
+	Sections int
+	Pages    int // pages with an AI summary
+	Changed  int // pages added or updated since DigestOptions.Since
+	Usage    ai.Usage
+	Cost     float64
+}
+
+// digestPage is a page with an AI summary included in the digest
+type digestPage struct {
+	link    database.Link
+	title   string
+	summary string
+}
+
+// WriteDigest builds a site overview from the AI summaries of the pages:
+// summaries are combined into an overview per URL section, and the section
+// overviews into an overview of the site. Results are cached like other AI
+// output, so regenerating an unchanged digest costs nothing.
+func WriteDigest(db *database.DB, store storage.Storage, opts DigestOptions) (*DigestReport, error) {
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return nil, fmt.Errorf("error listing completed links: %w", err)
+	}
+	seeds, err := db.GetSeedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("error listing seed URLs: %w", err)
+	}
+
+	summarized := make(map[string]*digestPage)
+	var pages []*digestPage
+	for _, link := range links {
+		summary := strings.TrimSpace(readAISummary(opts.OutputDir, seeds, link.URL))
+		if summary == "" {
+			continue
+		}
+		page := &digestPage{link: link, title: link.URL, summary: summary}
+		if content, err := store.Load(link.URL, opts.Format); err == nil {
+			if title := extractTitle(content); title != "" {
+				page.title = title
+			}
+		}
+		pages = append(pages, page)
+		summarized[link.URL] = page
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no AI summaries found, run stripper summarize first")
+	}
+
+	// Shallow pages come first, they make the best key links
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].link.Depth != pages[j].link.Depth {
+			return pages[i].link.Depth < pages[j].link.Depth
+		}
+		return pages[i].link.URL < pages[j].link.URL
+	})
+
+	var sections []string
+	bySection := make(map[string][]*digestPage)
+	for _, page := range pages {
+		section := urlSection(page.link.URL)
+		if _, ok := bySection[section]; !ok {
+			sections = append(sections, section)
+		}
+		bySection[section] = append(bySection[section], page)
+	}
+
+	client, err := newAIClient(db, opts.AI)
+	if err != nil {
+		return nil, err
+	}
+	meter, err := newUsageMeter(db, client, opts.AI, opts.AI.Model)
+	if err != nil {
+		return nil, err
+	}
+	report := &DigestReport{Sections: len(sections), Pages: len(pages)}
+	summarize := func(parts []string, prompt string) (string, error) {
+		input := strings.Join(parts, "\n\n---\n\n")
+		reserved, err := meter.reserve(opts.AI.Model, ai.EstimateTokens(input))
+		if err != nil {
+			return "", err
+		}
+		text, usage, err := client.Summarize(input, prompt)
+		meter.record(opts.Dest, opts.AI.Model, usage, reserved)
+		report.Usage.Add(usage)
+		return strings.TrimSpace(text), err
+	}
+
+	overviews := make(map[string]string, len(sections))
+	var sectionParts []string
+	for _, section := range sections {
+		var parts []string
+		for _, page := range bySection[section] {
+			parts = append(parts, fmt.Sprintf("%s (%s)\n\n%s", page.title, page.link.URL, page.summary))
+		}
+		overview, err := summarize(parts, sectionDigestPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("error summarizing section %s: %w", section, err)
+		}
+		overviews[section] = overview
+		sectionParts = append(sectionParts, fmt.Sprintf("%s\n\n%s", section, overview))
+	}
+
+	siteOverview, err := summarize(sectionParts, siteDigestPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("error summarizing site: %w", err)
+	}
+
+	title := opts.Title
+	if title == "" {
+		titles := make(map[string]string, len(pages))
+		for _, page := range pages {
+			titles[page.link.URL] = page.title
+		}
+		title = siteTitle(titles, seeds)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s\n\n", title))
+	b.WriteString(fmt.Sprintf("Digest of %d pages generated %s.\n\n", len(pages), time.Now().Format(time.RFC3339)))
+	b.WriteString(siteOverview + "\n")
+
+	if !opts.Since.IsZero() {
+		changes, err := db.GetChangedPages(opts.Since)
+		if err != nil {
+			return nil, fmt.Errorf("error listing changed pages: %w", err)
+		}
+		report.Changed = len(changes)
+
+		b.WriteString(fmt.Sprintf("\n## What Changed Since %s\n\n", opts.Since.Format("2006-01-02 15:04")))
+		if len(changes) == 0 {
+			b.WriteString("No pages were added or updated.\n")
+		} else {
+			var parts []string
+			for _, change := range changes {
+				if page, ok := summarized[change.URL]; ok {
+					parts = append(parts, fmt.Sprintf("%s (%s), %s\n\n%s", page.title, change.URL, changeKind(change), page.summary))
+				}
+			}
+			if len(parts) > 0 {
+				overview, err := summarize(parts, changesDigestPrompt)
+				if err != nil {
+					return nil, fmt.Errorf("error summarizing changes: %w", err)
+				}
+				b.WriteString(overview + "\n\n")
+			}
+			for _, change := range changes {
+				name := change.Title
+				if name == "" {
+					name = change.URL
+				}
+				b.WriteString(fmt.Sprintf("- [%s](%s) (%s)\n", name, change.URL, changeKind(change)))
+			}
+		}
+	}
+
+	linksPerSection := opts.Links
+	if linksPerSection <= 0 {
+		linksPerSection = DefaultDigestLinks
+	}
+	for _, section := range sections {
+		b.WriteString(fmt.Sprintf("\n## %s\n\n%s\n\n", section, overviews[section]))
+		for i, page := range bySection[section] {
+			if i == linksPerSection {
+				break
+			}
+			b.WriteString(fmt.Sprintf("- [%s](%s)", page.title, page.link.URL))
+			if description := firstSentence(page.summary); description != "" {
+				b.WriteString(": " + description)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if err := os.MkdirAll(path.Dir(opts.Dest), 0755); err != nil {
+		return nil, fmt.Errorf("error creating digest directory: %w", err)
+	}
+	if err := os.WriteFile(opts.Dest, []byte(b.String()), 0644); err != nil {
+		return nil, fmt.Errorf("error writing digest: %w", err)
+	}
+
+	_, report.Cost = meter.totals()
+	return report, nil
+}
+
+// changeKind describes a change for the digest
+func changeKind(change database.PageChange) string {
+	if change.New {
+		return "new"
+	}
+	return "updated"
+}
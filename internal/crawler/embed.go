@@ -0,0 +1,277 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// DefaultChunkTokens is the size of the chunks pages are cut into for
+// embedding when EmbedOptions.ChunkTokens is not set
+const DefaultChunkTokens = 512
+
+// ErrNoEmbeddings is returned by SemanticSearch when the archive has no
+// vectors for the embedding model
+var ErrNoEmbeddings = errors.New("no embeddings, run stripper embed first")
+
+// EmbedOptions configures building the vector index of an archive
+type EmbedOptions struct {
+	Format      string
+	Patterns    []string // URL globs; all pages when empty
+	All         bool     // re-embed pages whose content has not changed
+	ChunkTokens int
+	AI          AIOptions
+}
+
+// EmbedReport summarizes an indexing pass
+type EmbedReport struct {
+	Pages     int // pages embedded
+	Unchanged int // pages skipped because they are already indexed
+	Chunks    int
+	Usage     ai.Usage
+}
+
+// SearchResult is a chunk of a stored page matching a query
+type SearchResult struct {
+	URL     string
+	Heading string
+	Content string
+	Score   float64
+}
+
+// docChunk is a part of a page with the heading trail it falls under
+type docChunk struct {
+	heading string
+	text    string
+}
+
+// Embed chunks stored pages and stores their embedding vectors in the crawl
+// database. Pages whose content and embedding model are unchanged since they
+// were last indexed are skipped unless All is set.
+func Embed(db *database.DB, store storage.Storage, opts EmbedOptions) (*EmbedReport, error) {
+	client, err := newAIClient(db, opts.AI)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return nil, fmt.Errorf("error listing completed links: %w", err)
+	}
+
+	chunkTokens := opts.ChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = DefaultChunkTokens
+	}
+	model := client.EmbeddingModel()
+	meter, err := newUsageMeter(db, client, opts.AI, model)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EmbedReport{}
+	for _, link := range links {
+		if len(opts.Patterns) > 0 {
+			if _, ok := includeRank(link.URL, opts.Patterns, nil); !ok {
+				continue
+			}
+		}
+
+		content, err := store.Load(link.URL, opts.Format)
+		if err != nil {
+			debugf("Skipping %s for embedding: %v", link.URL, err)
+			continue
+		}
+		body := pageBody(content)
+		hash := ai.HashText(body)
+
+		if !opts.All {
+			indexedHash, indexedModel, err := db.GetEmbeddedHash(link.URL)
+			if err != nil {
+				return report, fmt.Errorf("error reading index for %s: %w", link.URL, err)
+			}
+			if indexedHash == hash && indexedModel == model {
+				report.Unchanged++
+				continue
+			}
+		}
+
+		chunks := chunkWithHeadings(body, chunkTokens)
+		if len(chunks) == 0 {
+			continue
+		}
+
+		// The heading trail is embedded with each chunk so that sections
+		// are found by the topic they belong to
+		texts := make([]string, len(chunks))
+		estimated := 0
+		for i, chunk := range chunks {
+			texts[i] = chunk.text
+			if chunk.heading != "" {
+				texts[i] = chunk.heading + "\n\n" + chunk.text
+			}
+			estimated += ai.EstimateTokens(texts[i])
+		}
+		reserved, err := meter.reserve(model, estimated)
+		if err != nil {
+			return report, err
+		}
+
+		vectors, usage, err := client.Embed(texts)
+		meter.record(link.URL, model, usage, reserved)
+		report.Usage.Add(usage)
+		if err != nil {
+			return report, fmt.Errorf("error embedding %s: %w", link.URL, err)
+		}
+
+		embeddings := make([]database.Embedding, len(chunks))
+		for i, chunk := range chunks {
+			embeddings[i] = database.Embedding{
+				URL:         link.URL,
+				Chunk:       i,
+				Heading:     chunk.heading,
+				Content:     chunk.text,
+				ContentHash: hash,
+				Model:       model,
+				Vector:      vectors[i],
+			}
+		}
+		if err := db.ReplaceEmbeddings(link.URL, embeddings); err != nil {
+			return report, fmt.Errorf("error storing embeddings for %s: %w", link.URL, err)
+		}
+
+		report.Pages++
+		report.Chunks += len(chunks)
+	}
+
+	return report, nil
+}
+
+// SemanticSearch embeds the query and returns the limit chunks closest to it
+func SemanticSearch(db *database.DB, opts AIOptions, query string, limit int) ([]SearchResult, error) {
+	client, err := newAIClient(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	meter, err := newUsageMeter(db, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	return semanticSearch(db, client, meter, query, limit)
+}
+
+// semanticSearch embeds the query with client, recording its usage with
+// meter, and returns the limit chunks closest to it
+func semanticSearch(db *database.DB, client *ai.Client, meter *usageMeter, query string, limit int) ([]SearchResult, error) {
+	model := client.EmbeddingModel()
+	embeddings, err := db.GetEmbeddings(model)
+	if err != nil {
+		return nil, fmt.Errorf("error reading embeddings: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("model %s: %w", model, ErrNoEmbeddings)
+	}
+
+	reserved, err := meter.reserve(model, ai.EstimateTokens(query))
+	if err != nil {
+		return nil, err
+	}
+	vectors, usage, err := client.Embed([]string{query})
+	meter.record("", model, usage, reserved)
+	if err != nil {
+		return nil, fmt.Errorf("error embedding query: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(embeddings))
+	for _, e := range embeddings {
+		results = append(results, SearchResult{
+			URL:     e.URL,
+			Heading: e.Heading,
+			Content: e.Content,
+			Score:   ai.CosineSimilarity(vectors[0], e.Vector),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// chunkWithHeadings splits markdown into chunks and records, for each chunk,
+// the trail of headings it falls under (e.g. "Install > Linux")
+func chunkWithHeadings(content string, maxTokens int) []docChunk {
+	type heading struct {
+		level int
+		text  string
+	}
+	var trail []heading
+	formatTrail := func() string {
+		names := make([]string, len(trail))
+		for i, h := range trail {
+			names[i] = h.text
+		}
+		return strings.Join(names, " > ")
+	}
+
+	var chunks []docChunk
+	inFence := false
+	for _, text := range ai.ChunkMarkdown(content, maxTokens) {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		chunk := docChunk{heading: formatTrail(), text: strings.TrimSpace(text)}
+		leading := true
+		for _, line := range strings.Split(text, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+				leading = false
+				continue
+			}
+			if inFence {
+				continue
+			}
+
+			level, title := markdownHeading(trimmed)
+			if level == 0 {
+				if trimmed != "" {
+					leading = false
+				}
+				continue
+			}
+
+			for len(trail) > 0 && trail[len(trail)-1].level >= level {
+				trail = trail[:len(trail)-1]
+			}
+			trail = append(trail, heading{level: level, text: title})
+
+			// A chunk that opens with a heading belongs to that section
+			if leading {
+				chunk.heading = formatTrail()
+				leading = false
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// markdownHeading returns the level and text of an ATX heading line, or 0
+func markdownHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "# "))
+}
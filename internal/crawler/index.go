@@ -0,0 +1,218 @@
+package crawler
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// htmlTitlePattern matches the title element of stored HTML pages
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// indexNode is one segment of the URL hierarchy used to build the index
+type indexNode struct {
+	name     string
+	page     *indexPage
+	children map[string]*indexNode
+}
+
+// indexPage describes a stored page listed in the index
+type indexPage struct {
+	link    database.Link
+	title   string
+	file    string
+	summary string // AI summary file, empty when none exists
+}
+
+// writeIndex regenerates index.md, and optionally index.html, in the output
+// directory from the completed links in the database. Pages are linked by
+// their uncompressed file names, which compressed archives only have once
+// decompressed.
+func (c *Crawler) writeIndex() error {
+	links, err := c.db.GetLinksByStatus("completed")
+	if err != nil {
+		return fmt.Errorf("error listing completed links: %w", err)
+	}
+
+	root := &indexNode{children: make(map[string]*indexNode)}
+	for _, link := range links {
+		u, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+
+		page := &indexPage{
+			link:  link,
+			title: link.URL,
+			file:  storage.Filename(link.URL, c.format),
+		}
+		if content, err := c.storage.Load(link.URL, c.format); err == nil {
+			if title := extractTitle(content); title != "" {
+				page.title = title
+			}
+		}
+		summary := path.Join("ai", c.aiSummaryFilename(link.URL))
+		if _, err := os.Stat(path.Join(c.outputDir, summary)); err == nil {
+			page.summary = summary
+		}
+
+		node := root.child(u.Host)
+		for _, segment := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+			if segment != "" {
+				node = node.child(segment)
+			}
+		}
+		if u.RawQuery != "" {
+			node = node.child("?" + u.RawQuery)
+		}
+		node.page = page
+	}
+
+	generated := time.Now().Format(time.RFC3339)
+
+	var md strings.Builder
+	md.WriteString("# Crawl Index\n\n")
+	md.WriteString(fmt.Sprintf("Generated %s from %d pages.\n", generated, len(links)))
+	for _, host := range root.sorted() {
+		md.WriteString(fmt.Sprintf("\n## %s\n\n", host.name))
+		// The host node itself is a page when the site root was crawled
+		if host.page != nil {
+			host.page.writeMarkdown(&md, "")
+		}
+		host.writeMarkdown(&md, 0)
+	}
+	if err := os.WriteFile(path.Join(c.outputDir, "index.md"), []byte(md.String()), 0644); err != nil {
+		return fmt.Errorf("error writing index.md: %w", err)
+	}
+
+	if !c.indexHTML {
+		return nil
+	}
+
+	var h strings.Builder
+	h.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Crawl Index</title>\n</head>\n<body>\n")
+	h.WriteString("<h1>Crawl Index</h1>\n")
+	h.WriteString(fmt.Sprintf("<p>Generated %s from %d pages.</p>\n", generated, len(links)))
+	for _, host := range root.sorted() {
+		h.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(host.name)))
+		if host.page != nil {
+			h.WriteString("<p>")
+			host.page.writeHTML(&h)
+			h.WriteString("</p>\n")
+		}
+		host.writeHTML(&h)
+	}
+	h.WriteString("</body>\n</html>\n")
+	if err := os.WriteFile(path.Join(c.outputDir, "index.html"), []byte(h.String()), 0644); err != nil {
+		return fmt.Errorf("error writing index.html: %w", err)
+	}
+
+	return nil
+}
+
+// child returns the named child node, creating it if needed
+func (n *indexNode) child(name string) *indexNode {
+	if n.children[name] == nil {
+		n.children[name] = &indexNode{name: name, children: make(map[string]*indexNode)}
+	}
+	return n.children[name]
+}
+
+// sorted returns the child nodes ordered by name
+func (n *indexNode) sorted() []*indexNode {
+	nodes := make([]*indexNode, 0, len(n.children))
+	for _, child := range n.children {
+		nodes = append(nodes, child)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+	return nodes
+}
+
+// details describes the depth and crawl time of a page
+func (p *indexPage) details() string {
+	details := fmt.Sprintf("depth %d", p.link.Depth)
+	if !p.link.LastCrawled.IsZero() {
+		details += ", crawled " + p.link.LastCrawled.Format("2006-01-02 15:04")
+	}
+	return details
+}
+
+// writeMarkdown renders the children of a node as a nested markdown list
+func (n *indexNode) writeMarkdown(b *strings.Builder, level int) {
+	indent := strings.Repeat("  ", level)
+
+	for _, child := range n.sorted() {
+		if child.page != nil {
+			child.page.writeMarkdown(b, indent)
+		} else {
+			b.WriteString(fmt.Sprintf("%s- %s/\n", indent, child.name))
+		}
+		child.writeMarkdown(b, level+1)
+	}
+}
+
+// writeMarkdown renders a single page entry
+func (p *indexPage) writeMarkdown(b *strings.Builder, indent string) {
+	title := strings.NewReplacer("[", "\\[", "]", "\\]").Replace(p.title)
+	b.WriteString(fmt.Sprintf("%s- [%s](%s) (%s)", indent, title, p.file, p.details()))
+	if p.summary != "" {
+		b.WriteString(fmt.Sprintf(" · [AI summary](%s)", p.summary))
+	}
+	b.WriteString("\n")
+}
+
+// writeHTML renders the children of a node as a nested HTML list
+func (n *indexNode) writeHTML(b *strings.Builder) {
+	b.WriteString("<ul>\n")
+	for _, child := range n.sorted() {
+		b.WriteString("<li>")
+		if child.page != nil {
+			child.page.writeHTML(b)
+		} else {
+			b.WriteString(html.EscapeString(child.name) + "/")
+		}
+		if len(child.children) > 0 {
+			b.WriteString("\n")
+			child.writeHTML(b)
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+}
+
+// writeHTML renders a single page entry
+func (p *indexPage) writeHTML(b *strings.Builder) {
+	b.WriteString(fmt.Sprintf(`<a href="%s">%s</a> <small>(%s)</small>`,
+		html.EscapeString(p.file), html.EscapeString(p.title), p.details()))
+	if p.summary != "" {
+		b.WriteString(fmt.Sprintf(` · <a href="%s">AI summary</a>`, html.EscapeString(p.summary)))
+	}
+}
+
+// extractTitle finds a page title in stored content, using the Reader API
+// "Title:" line when present and the first markdown heading or HTML title
+// otherwise
+func extractTitle(content string) string {
+	if m := htmlTitlePattern.FindStringSubmatch(content); m != nil {
+		return html.UnescapeString(strings.TrimSpace(m[1]))
+	}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Title:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Title:"))
+		}
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,289 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stripper/internal/database"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// maxRedirects is the number of redirects followed for one request, the
+// default of net/http
+const maxRedirects = 10
+
+// maxSitemaps limits how many sitemaps of a sitemap index are read
+const maxSitemaps = 100
+
+// defaultSitemapPriority is the priority of sitemap URLs that do not set one
+const defaultSitemapPriority = 0.5
+
+// statusError is an HTTP error response to a page request
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d %s", e.code, http.StatusText(e.code))
+}
+
+// offsiteRedirectError is a redirect to a host that is not crawled
+type offsiteRedirectError struct {
+	target string
+}
+
+func (e *offsiteRedirectError) Error() string {
+	return fmt.Sprintf("redirect to %s is not followed, the host is not crawled", e.target)
+}
+
+// RedirectChain is a sequence of redirects from a URL to its final target
+type RedirectChain struct {
+	Source string   // URL that was requested
+	Target string   // final URL
+	Hops   []string // every URL in the chain, starting with Source
+}
+
+// trackEdges makes a collector record the redirects it follows and forget the
+// links of a page before the page is scanned again. Redirects to other hosts
+// are not followed. When chain is not nil, the hops of the collector's
+// redirects are appended to it.
+func (c *Crawler) trackEdges(collector *colly.Collector, chain *[]database.Redirect) {
+	collector.SetRedirectHandler(func(req *http.Request, via []*http.Request) error {
+		from := via[len(via)-1]
+		hop := database.Redirect{URL: from.URL.String()}
+		if req.Response != nil {
+			hop.Status = req.Response.StatusCode
+		}
+		if chain != nil {
+			*chain = append(*chain, hop)
+		}
+
+		edge := database.Edge{
+			Source: hop.URL,
+			Target: req.URL.String(),
+			Kind:   "redirect",
+		}
+		if err := c.db.SaveEdge(edge); err != nil {
+			debugf("Error recording redirect from %s: %v", edge.Source, err)
+		}
+
+		if !c.allowedHost(req.URL) {
+			return &offsiteRedirectError{target: req.URL.String()}
+		}
+		if len(via) >= maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	})
+
+	collector.OnResponse(func(r *colly.Response) {
+		if err := c.db.DeleteEdgesFrom(r.Request.URL.String(), "link"); err != nil {
+			debugf("Error clearing links of %s: %v", r.Request.URL, err)
+		}
+	})
+}
+
+// allowedHost reports whether URLs on the host of u may be crawled
+func (c *Crawler) allowedHost(u *url.URL) bool {
+	return u.Host == c.baseURL.Host
+}
+
+// recordLink records a link found on a page
+func (c *Crawler) recordLink(e *colly.HTMLElement, target string) {
+	source := e.Request.URL.String()
+	if source == target {
+		return
+	}
+	edge := database.Edge{
+		Source: source,
+		Target: target,
+		Kind:   "link",
+		Anchor: strings.Join(strings.Fields(e.Text), " "),
+		Rel:    e.Attr("rel"),
+	}
+	if err := c.db.SaveEdge(edge); err != nil {
+		debugf("Error recording link from %s to %s: %v", source, target, err)
+	}
+}
+
+// RedirectChains follows the recorded redirects from every URL that is not
+// itself a redirect target to its final URL
+func RedirectChains(db *database.DB) ([]RedirectChain, error) {
+	edges, err := db.GetEdges("redirect")
+	if err != nil {
+		return nil, fmt.Errorf("error listing redirects: %w", err)
+	}
+
+	next := make(map[string]string, len(edges))
+	targets := make(map[string]bool, len(edges))
+	for _, edge := range edges {
+		next[edge.Source] = edge.Target
+		targets[edge.Target] = true
+	}
+
+	var chains []RedirectChain
+	for _, edge := range edges {
+		if targets[edge.Source] {
+			continue
+		}
+		chain := RedirectChain{Source: edge.Source, Hops: []string{edge.Source}}
+		seen := map[string]bool{edge.Source: true}
+		for u, ok := next[edge.Source]; ok; u, ok = next[u] {
+			chain.Hops = append(chain.Hops, u)
+			if seen[u] {
+				break // redirect loop
+			}
+			seen[u] = true
+		}
+		chain.Target = chain.Hops[len(chain.Hops)-1]
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+// Orphans returns the candidate URLs no other page links to, apart from the
+// seed URLs. Without candidates, every queued link is checked.
+func Orphans(db *database.DB, candidates []string) ([]string, error) {
+	counts, err := db.GetInboundCounts()
+	if err != nil {
+		return nil, fmt.Errorf("error counting inbound links: %w", err)
+	}
+	seeds, err := db.GetSeedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("error listing seed URLs: %w", err)
+	}
+
+	inbound := make(map[string]int, len(counts))
+	for _, count := range counts {
+		inbound[count.URL] = count.Inbound
+	}
+	if candidates == nil {
+		for _, count := range counts {
+			candidates = append(candidates, count.URL)
+		}
+	}
+
+	// Pages reached through a redirect count as linked when the
+	// redirecting URL is
+	chains, err := RedirectChains(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range chains {
+		if inbound[chain.Source] > 0 {
+			inbound[chain.Target] += inbound[chain.Source]
+		}
+	}
+
+	isSeed := make(map[string]bool, len(seeds))
+	for _, seed := range seeds {
+		isSeed[seed] = true
+	}
+
+	var orphans []string
+	seen := make(map[string]bool, len(candidates))
+	for _, u := range candidates {
+		if seen[u] || isSeed[u] || inbound[u] > 0 {
+			continue
+		}
+		seen[u] = true
+		orphans = append(orphans, u)
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// sitemap is a sitemap or a sitemap index
+type sitemap struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc      string `xml:"loc"`
+		Priority string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// SitemapEntry is a page listed in a sitemap
+type SitemapEntry struct {
+	URL      string
+	Priority float64 // 0 to 1, 0.5 when the sitemap does not set it
+}
+
+// ReadSitemap returns the page URLs listed in a sitemap, read from a URL or a
+// file. The sitemaps of a sitemap index are read as well.
+func ReadSitemap(location string) ([]string, error) {
+	entries, err := ReadSitemapEntries(location)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, entry.URL)
+	}
+	return urls, nil
+}
+
+// ReadSitemapEntries returns the pages listed in a sitemap with their
+// priorities, like ReadSitemap
+func ReadSitemapEntries(location string) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	queue := []string{location}
+	for read := 0; len(queue) > 0; read++ {
+		if read == maxSitemaps {
+			return nil, fmt.Errorf("sitemap index lists more than %d sitemaps", maxSitemaps)
+		}
+		loc := queue[0]
+		queue = queue[1:]
+
+		data, err := readLocation(loc)
+		if err != nil {
+			return nil, fmt.Errorf("error reading sitemap %s: %w", loc, err)
+		}
+		var sm sitemap
+		if err := xml.Unmarshal(data, &sm); err != nil {
+			return nil, fmt.Errorf("error parsing sitemap %s: %w", loc, err)
+		}
+		for _, u := range sm.URLs {
+			entry := SitemapEntry{URL: strings.TrimSpace(u.Loc), Priority: defaultSitemapPriority}
+			if p := strings.TrimSpace(u.Priority); p != "" {
+				priority, err := strconv.ParseFloat(p, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid priority %q for %s in sitemap %s", p, entry.URL, loc)
+				}
+				entry.Priority = priority
+			}
+			entries = append(entries, entry)
+		}
+		for _, s := range sm.Sitemaps {
+			queue = append(queue, strings.TrimSpace(s.Loc))
+		}
+	}
+	return entries, nil
+}
+
+// readLocation reads a file or fetches a URL
+func readLocation(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+	return io.ReadAll(resp.Body)
+}
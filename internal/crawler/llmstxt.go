@@ -0,0 +1,253 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// maxDescriptionLength caps the one-line page descriptions in llms.txt
+const maxDescriptionLength = 160
+
+// LLMsTxtOptions configures llms.txt generation
+type LLMsTxtOptions struct {
+	OutputDir string   // crawl output directory
+	DestDir   string   // directory the files are written to
+	Format    string   // format of the stored pages
+	Title     string   // site title, derived from the root page when empty
+	Include   []string // URL globs; pages are ordered by the first matching pattern
+	Exclude   []string // URL globs of pages to leave out
+	MaxSize   int      // maximum size of llms-full.txt in bytes, 0 for no limit
+}
+
+// LLMsTxtReport summarizes a generated llms.txt
+type LLMsTxtReport struct {
+	Pages     int // pages listed in llms.txt
+	FullPages int // pages included in llms-full.txt
+	Skipped   int // pages left out of llms-full.txt due to the size limit
+}
+
+// llmsPage is a page selected for llms.txt
+type llmsPage struct {
+	link        database.Link
+	rank        int
+	section     string
+	title       string
+	description string
+	body        string
+}
+
+// WriteLLMsTxt generates llms.txt and llms-full.txt from a crawled site,
+// describing each page with its AI summary when one is available
+func WriteLLMsTxt(db *database.DB, store storage.Storage, opts LLMsTxtOptions) (*LLMsTxtReport, error) {
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return nil, fmt.Errorf("error listing completed links: %w", err)
+	}
+	seeds, err := db.GetSeedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("error listing seed URLs: %w", err)
+	}
+
+	var pages []*llmsPage
+	for _, link := range links {
+		rank, ok := includeRank(link.URL, opts.Include, opts.Exclude)
+		if !ok {
+			continue
+		}
+
+		content, err := store.Load(link.URL, opts.Format)
+		if err != nil {
+			debugf("Skipping %s for llms.txt: %v", link.URL, err)
+			continue
+		}
+
+		page := &llmsPage{
+			link:    link,
+			rank:    rank,
+			section: urlSection(link.URL),
+			title:   extractTitle(content),
+			body:    strings.TrimSpace(pageBody(content)),
+		}
+		if page.title == "" {
+			page.title = link.URL
+		}
+
+		summary := readAISummary(opts.OutputDir, seeds, link.URL)
+		page.description = firstSentence(summary)
+		if page.description == "" {
+			page.description = firstSentence(page.body)
+		}
+
+		pages = append(pages, page)
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].rank != pages[j].rank {
+			return pages[i].rank < pages[j].rank
+		}
+		return pages[i].link.URL < pages[j].link.URL
+	})
+
+	title := opts.Title
+	if title == "" {
+		titles := make(map[string]string, len(pages))
+		for _, page := range pages {
+			titles[page.link.URL] = page.title
+		}
+		title = siteTitle(titles, seeds)
+	}
+
+	// Sections keep the order in which their first page appears
+	var sections []string
+	bySection := make(map[string][]*llmsPage)
+	for _, page := range pages {
+		if _, ok := bySection[page.section]; !ok {
+			sections = append(sections, page.section)
+		}
+		bySection[page.section] = append(bySection[page.section], page)
+	}
+
+	var index strings.Builder
+	index.WriteString(fmt.Sprintf("# %s\n", title))
+	for _, section := range sections {
+		index.WriteString(fmt.Sprintf("\n## %s\n\n", section))
+		for _, page := range bySection[section] {
+			index.WriteString(fmt.Sprintf("- [%s](%s)", page.title, page.link.URL))
+			if page.description != "" {
+				index.WriteString(": " + page.description)
+			}
+			index.WriteString("\n")
+		}
+	}
+
+	report := &LLMsTxtReport{Pages: len(pages)}
+
+	var full strings.Builder
+	full.WriteString(fmt.Sprintf("# %s\n", title))
+	for _, section := range sections {
+		for _, page := range bySection[section] {
+			entry := fmt.Sprintf("\n---\n\n# %s\n\nSource: %s\n\n%s\n", page.title, page.link.URL, page.body)
+			if opts.MaxSize > 0 && full.Len()+len(entry) > opts.MaxSize {
+				report.Skipped++
+				continue
+			}
+			full.WriteString(entry)
+			report.FullPages++
+		}
+	}
+
+	if err := os.MkdirAll(opts.DestDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating destination directory: %w", err)
+	}
+	if err := os.WriteFile(path.Join(opts.DestDir, "llms.txt"), []byte(index.String()), 0644); err != nil {
+		return nil, fmt.Errorf("error writing llms.txt: %w", err)
+	}
+	if err := os.WriteFile(path.Join(opts.DestDir, "llms-full.txt"), []byte(full.String()), 0644); err != nil {
+		return nil, fmt.Errorf("error writing llms-full.txt: %w", err)
+	}
+
+	return report, nil
+}
+
+// includeRank returns the index of the first include pattern matching the URL
+// and whether the URL should be included at all
+func includeRank(pageURL string, include []string, exclude []string) (int, bool) {
+	for _, pattern := range exclude {
+		if globMatch(pattern, pageURL) {
+			return 0, false
+		}
+	}
+	if len(include) == 0 {
+		return 0, true
+	}
+	for i, pattern := range include {
+		if globMatch(pattern, pageURL) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// urlSection names the section of a page after the first segment of its path
+func urlSection(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "Pages"
+	}
+
+	segment, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if segment == "" || path.Ext(segment) != "" {
+		return "Pages"
+	}
+
+	words := strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' })
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// siteTitle picks the title of the seed page, given page titles keyed by
+// URL, falling back to its host
+func siteTitle(titles map[string]string, seeds []string) string {
+	for _, seed := range seeds {
+		if title, ok := titles[seed]; ok && title != seed {
+			return title
+		}
+	}
+	for _, seed := range seeds {
+		if u, err := url.Parse(seed); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return "Site"
+}
+
+// readAISummary returns the stored AI summary of a page, or an empty string.
+// Summaries are named relative to the seed URL of the crawl, so the longest
+// seed that prefixes the page URL is used.
+func readAISummary(outputDir string, seeds []string, pageURL string) string {
+	base := seedFor(seeds, pageURL)
+	if base == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(path.Join(outputDir, "ai", AISummaryFilename(base, pageURL)))
+	if err != nil {
+		return ""
+	}
+	return stripFrontMatter(string(data))
+}
+
+// firstSentence returns the first line of prose in markdown content, cut down
+// to a single short sentence
+func firstSentence(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*> ")
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "|") ||
+			strings.HasPrefix(line, "```") || strings.HasPrefix(line, "![") {
+			continue
+		}
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+
+		if idx := strings.Index(line, ". "); idx != -1 {
+			line = line[:idx+1]
+		}
+		if runes := []rune(line); len(runes) > maxDescriptionLength {
+			line = strings.TrimSpace(string(runes[:maxDescriptionLength])) + "…"
+		}
+		return line
+	}
+	return ""
+}
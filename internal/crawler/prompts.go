@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"stripper/internal/database"
+)
+
+// PromptRule selects a prompt template for pages whose URL matches a glob or
+// a regular expression. A rule without either matches every page.
+type PromptRule struct {
+	Pattern  string // URL glob, * matches any characters
+	Regex    string // URL regular expression
+	Template string // inline text/template source
+	File     string // file holding the template source
+}
+
+// PromptVars are the variables available to prompt templates
+type PromptVars struct {
+	URL       string
+	Title     string
+	Depth     int
+	CrawledAt time.Time
+}
+
+// promptTemplate is a compiled prompt rule
+type promptTemplate struct {
+	pattern string
+	regex   *regexp.Regexp
+	tmpl    *template.Template
+}
+
+// compilePrompts parses prompt rules in order, reading templates from files
+// where configured
+func compilePrompts(rules []PromptRule) ([]promptTemplate, error) {
+	templates := make([]promptTemplate, 0, len(rules))
+	for i, rule := range rules {
+		name := fmt.Sprintf("prompt %d", i+1)
+		if rule.Pattern != "" && rule.Regex != "" {
+			return nil, fmt.Errorf("%s: set either pattern or regex, not both", name)
+		}
+
+		source := rule.Template
+		if rule.File != "" {
+			if source != "" {
+				return nil, fmt.Errorf("%s: set either template or file, not both", name)
+			}
+			data, err := os.ReadFile(rule.File)
+			if err != nil {
+				return nil, fmt.Errorf("%s: failed to read template: %w", name, err)
+			}
+			source = string(data)
+			name = rule.File
+		}
+		if strings.TrimSpace(source) == "" {
+			return nil, fmt.Errorf("%s: template is empty", name)
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt template: %w", err)
+		}
+
+		pt := promptTemplate{pattern: rule.Pattern, tmpl: tmpl}
+		if rule.Regex != "" {
+			if pt.regex, err = regexp.Compile(rule.Regex); err != nil {
+				return nil, fmt.Errorf("%s: invalid regex: %w", name, err)
+			}
+		}
+		templates = append(templates, pt)
+	}
+	return templates, nil
+}
+
+// matches reports whether the template applies to a URL
+func (p promptTemplate) matches(pageURL string) bool {
+	switch {
+	case p.regex != nil:
+		return p.regex.MatchString(pageURL)
+	case p.pattern != "":
+		return globMatch(p.pattern, pageURL)
+	}
+	return true
+}
+
+// promptFor renders the prompt of the first rule matching the page, falling
+// back to the configured prompt for the AI mode
+func (s *aiStage) promptFor(link database.Link, content string) (string, error) {
+	for _, p := range s.prompts {
+		if !p.matches(link.URL) {
+			continue
+		}
+
+		vars := PromptVars{
+			URL:       link.URL,
+			Title:     extractTitle(content),
+			Depth:     link.Depth,
+			CrawledAt: link.LastCrawled,
+		}
+		var b strings.Builder
+		if err := p.tmpl.Execute(&b, vars); err != nil {
+			return "", fmt.Errorf("error rendering prompt template: %w", err)
+		}
+		return b.String(), nil
+	}
+
+	if s.mode == "extract" {
+		return s.extractPrompt, nil
+	}
+	return s.systemPrompt, nil
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"path/filepath"
+	"testing"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+func TestPromptMatchesBetweenCrawlAndSummarize(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "crawler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const pageURL = "https://example.com/docs/install"
+	if err := db.QueueLink(pageURL, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpdateLinkStatus(pageURL, "completed", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts, err := compilePrompts([]PromptRule{{
+		Pattern:  "*/docs/*",
+		Template: "Summarize {{.Title}} ({{.URL}}, depth {{.Depth}}) crawled {{.CrawledAt}}",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &aiStage{prompts: prompts}
+	content := "# Install\n\nRun the installer."
+
+	// The crawl submits the link it just stored
+	c := &Crawler{db: db}
+	crawled := c.storedLink(database.Link{URL: pageURL, Depth: 1})
+	crawlPrompt, err := s.promptFor(crawled, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stripper summarize reads the completed links back
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil || len(links) != 1 {
+		t.Fatalf("GetLinksByStatus: %v, %d links", err, len(links))
+	}
+	summarizePrompt, err := s.promptFor(links[0], content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if crawled.LastCrawled.IsZero() {
+		t.Error("crawl time not read back")
+	}
+	if ai.HashText(crawlPrompt) != ai.HashText(summarizePrompt) {
+		t.Errorf("prompts differ:\ncrawl:     %q\nsummarize: %q", crawlPrompt, summarizePrompt)
+	}
+}
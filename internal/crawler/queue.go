@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+
+	"stripper/internal/config"
+	"stripper/internal/database"
+)
+
+// QueueOptions configures the order in which queued links are fetched
+type QueueOptions struct {
+	Strategy      string      // "bfs" (default), "dfs" or "priority"
+	Sitemap       string      // sitemap URL or file whose priorities are added to a page's priority
+	InboundWeight float64     // priority added for every page linking to a page
+	Weights       []URLWeight // priority added to pages matching a URL pattern
+}
+
+// URLWeight adds to the priority of pages whose URL matches a glob or a
+// regular expression
+type URLWeight struct {
+	Pattern string // URL glob, * matches any characters
+	Regex   string // URL regular expression
+	Weight  float64
+}
+
+// QueueOptionsFromConfig builds queue options from the crawler.queue
+// configuration
+func QueueOptionsFromConfig(cfg *config.Config) QueueOptions {
+	opts := QueueOptions{
+		Strategy:      cfg.Crawler.Queue.Strategy,
+		Sitemap:       cfg.Crawler.Queue.Sitemap,
+		InboundWeight: cfg.Crawler.Queue.InboundWeight,
+	}
+	for _, w := range cfg.Crawler.Queue.Weights {
+		opts.Weights = append(opts.Weights, URLWeight{
+			Pattern: w.Pattern,
+			Regex:   w.Regex,
+			Weight:  w.Weight,
+		})
+	}
+	return opts
+}
+
+// queue computes the priority of links and the order they are fetched in.
+// The priority of a page is its sitemap priority plus the weights of the URL
+// patterns it matches; pages linking to it are counted when a batch is
+// selected, since they are still being discovered.
+type queue struct {
+	order    database.QueueOrder
+	sitemap  map[string]float64
+	patterns []urlPattern
+}
+
+// urlPattern is a compiled URL weight
+type urlPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+	weight  float64
+}
+
+// newQueue validates the strategy, compiles the URL weights and reads the
+// sitemap priorities, which only the priority strategy uses
+func newQueue(opts QueueOptions) (*queue, error) {
+	q := &queue{order: database.QueueOrder{
+		Strategy:      opts.Strategy,
+		InboundWeight: opts.InboundWeight,
+	}}
+	switch opts.Strategy {
+	case "":
+		q.order.Strategy = database.StrategyBFS
+	case database.StrategyBFS, database.StrategyDFS:
+	case database.StrategyPriority:
+		if err := q.loadPriorities(opts); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported queue strategy: %s (use bfs, dfs or priority)", opts.Strategy)
+	}
+	return q, nil
+}
+
+// loadPriorities compiles the URL weights and reads the sitemap priorities
+func (q *queue) loadPriorities(opts QueueOptions) error {
+	for i, w := range opts.Weights {
+		if (w.Pattern == "") == (w.Regex == "") {
+			return fmt.Errorf("queue weight %d: set either pattern or regex", i+1)
+		}
+		p := urlPattern{pattern: w.Pattern, weight: w.Weight}
+		if w.Regex != "" {
+			var err error
+			if p.regex, err = regexp.Compile(w.Regex); err != nil {
+				return fmt.Errorf("queue weight %d: invalid regex: %w", i+1, err)
+			}
+		}
+		q.patterns = append(q.patterns, p)
+	}
+
+	if opts.Sitemap != "" {
+		entries, err := ReadSitemapEntries(opts.Sitemap)
+		if err != nil {
+			return err
+		}
+		q.sitemap = make(map[string]float64, len(entries))
+		for _, entry := range entries {
+			q.sitemap[entry.URL] = entry.Priority
+		}
+	}
+	return nil
+}
+
+// priority returns the static priority of a page
+func (q *queue) priority(pageURL string) float64 {
+	priority := q.sitemap[pageURL]
+	for _, p := range q.patterns {
+		if p.matches(pageURL) {
+			priority += p.weight
+		}
+	}
+	return priority
+}
+
+// matches reports whether the weight applies to a URL
+func (p urlPattern) matches(pageURL string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(pageURL)
+	}
+	return globMatch(p.pattern, pageURL)
+}
+
+// queueLink queues a link with its priority
+func (c *Crawler) queueLink(pageURL string, depth int) error {
+	return c.db.QueueLink(pageURL, depth, c.queue.priority(pageURL))
+}
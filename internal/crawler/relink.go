@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// ErrCompressedPages is returned when relinking an archive whose pages are
+// stored compressed
+var ErrCompressedPages = errors.New("pages are stored compressed and browsers cannot follow links to compressed files; recrawl without compression to relink")
+
+// DanglingLink is an internal link to a page that is not in the archive
+type DanglingLink struct {
+	Page   string
+	Target string
+}
+
+// RelinkOptions configures a relink pass
+type RelinkOptions struct {
+	Format string
+	Ignore []string // file extensions the crawler does not follow
+}
+
+// RelinkReport summarizes a relink pass
+type RelinkReport struct {
+	Pages     int // pages scanned
+	Updated   int // pages with at least one rewritten link
+	Rewritten int // links rewritten to local files
+	Dangling  []DanglingLink
+}
+
+// Relink rewrites links between archived pages into relative links to the
+// stored files, following recorded redirects. External links are left
+// untouched and internal links to pages missing from the archive are reported
+// as dangling, unless the crawler would not have followed them either. Archives with compressed pages are refused with
+// ErrCompressedPages.
+func Relink(db *database.DB, store *storage.FileStorage, opts RelinkOptions) (*RelinkReport, error) {
+	format := opts.Format
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return nil, fmt.Errorf("error listing completed links: %w", err)
+	}
+
+	// Map archived URLs to their stored files
+	archived := make(map[string]string, len(links))
+	localFiles := make(map[string]bool, len(links))
+	hosts := make(map[string]bool)
+	for _, link := range links {
+		filename := storage.Filename(link.URL, format)
+		if stored := store.StoredFilename(link.URL, format); stored != filename {
+			return nil, fmt.Errorf("cannot relink %s: %w", stored, ErrCompressedPages)
+		}
+		archived[normalizeLinkURL(link.URL)] = filename
+		localFiles[filename] = true
+		if u, err := url.Parse(link.URL); err == nil {
+			hosts[u.Host] = true
+		}
+	}
+
+	// Links to a redirecting URL point at the page it redirects to
+	redirected, err := db.GetLinksByStatus("redirected")
+	if err != nil {
+		return nil, fmt.Errorf("error listing redirected links: %w", err)
+	}
+	for _, link := range redirected {
+		if filename, ok := archived[normalizeLinkURL(link.FinalURL)]; ok {
+			archived[normalizeLinkURL(link.URL)] = filename
+		}
+	}
+
+	pattern := markdownRefPattern
+	if format == "html" {
+		pattern = htmlRefPattern
+	}
+
+	report := &RelinkReport{}
+	for _, link := range links {
+		content, err := store.Load(link.URL, format)
+		if err != nil {
+			debugf("Skipping relink for %s: %v", link.URL, err)
+			continue
+		}
+		report.Pages++
+
+		base, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+
+		rewritten := 0
+		updated := rewriteRefs(content, pattern, func(kind, target string) string {
+			// Links already pointing at local files are left alone so
+			// relinking is idempotent
+			local, _, _ := strings.Cut(target, "#")
+			if localFiles[local] || strings.HasPrefix(local, "assets/") {
+				return target
+			}
+
+			ref, err := base.Parse(target)
+			if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") || !hosts[ref.Host] {
+				return target
+			}
+
+			filename, ok := archived[normalizeLinkURL(ref.String())]
+			if !ok {
+				if kind != "!" && !strings.EqualFold(kind, "img") && !shouldIgnoreURL(ref.String(), opts.Ignore) {
+					report.Dangling = append(report.Dangling, DanglingLink{Page: link.URL, Target: ref.String()})
+				}
+				return target
+			}
+
+			rewritten++
+			if ref.Fragment != "" {
+				return filename + "#" + ref.Fragment
+			}
+			return filename
+		})
+
+		if rewritten == 0 {
+			continue
+		}
+		if err := store.Rewrite(link.URL, updated, format); err != nil {
+			return report, fmt.Errorf("error saving relinked content for %s: %w", link.URL, err)
+		}
+		report.Updated++
+		report.Rewritten += rewritten
+	}
+
+	return report, nil
+}
+
+// normalizeLinkURL reduces a URL to the form used to match links against
+// archived pages: no fragment and no trailing slash
+func normalizeLinkURL(rawURL string) string {
+	if idx := strings.Index(rawURL, "#"); idx != -1 {
+		rawURL = rawURL[:idx]
+	}
+	return strings.TrimRight(rawURL, "/")
+}
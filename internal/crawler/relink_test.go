@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// newRelinkArchive stores the given pages, keyed by URL, as completed links
+// in a new output directory
+func newRelinkArchive(t *testing.T, compression string, pages map[string]string) (*database.DB, *storage.FileStorage) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := database.New(filepath.Join(dir, "crawler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := storage.NewFileStorage(dir, storage.Options{Compression: compression})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for pageURL, content := range pages {
+		if err := db.QueueLink(pageURL, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.UpdateLinkStatus(pageURL, "completed", 1, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Save(pageURL, content, "markdown"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, store
+}
+
+func TestRelink(t *testing.T) {
+	db, store := newRelinkArchive(t, "", map[string]string{
+		"https://example.com/":      "[Guide](/guide#setup) [Missing](/missing) [Out](https://other.org/)",
+		"https://example.com/guide": "# Guide",
+	})
+
+	// The crawler never follows links to ignored extensions, so they are
+	// not dangling
+	report, err := Relink(db, store, RelinkOptions{Format: "markdown", Ignore: []string{"pdf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DanglingLink{{Page: "https://example.com/", Target: "https://example.com/missing"}}
+	if report.Rewritten != 1 || !reflect.DeepEqual(report.Dangling, want) {
+		t.Errorf("report = %+v, want 1 rewritten link and dangling links %+v", report, want)
+	}
+
+	content, err := store.Load("https://example.com/", "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	guide := storage.Filename("https://example.com/guide", "markdown")
+	if !strings.Contains(content, "[Guide]("+guide+"#setup)") || !strings.Contains(content, "[Out](https://other.org/)") {
+		t.Errorf("relinked content = %q", content)
+	}
+}
+
+func TestRelinkRefusesCompressedPages(t *testing.T) {
+	const home = "[Guide](/guide)"
+	db, store := newRelinkArchive(t, "gzip", map[string]string{
+		"https://example.com/":      home,
+		"https://example.com/guide": "# Guide",
+	})
+
+	if _, err := Relink(db, store, RelinkOptions{Format: "markdown"}); !errors.Is(err, ErrCompressedPages) {
+		t.Fatalf("Relink error = %v, want ErrCompressedPages", err)
+	}
+	content, err := store.Load("https://example.com/", "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, home) {
+		t.Errorf("refused relink changed the page: %q", content)
+	}
+
+	if _, err := New(Options{URL: "https://example.com/", OutputDir: t.TempDir(), Compression: "zstd", Relink: true}); !errors.Is(err, ErrCompressedPages) {
+		t.Errorf("New error = %v, want ErrCompressedPages", err)
+	}
+}
@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+const (
+	maxAIRetries     = 5
+	aiBackoffInitial = 5 * time.Second
+	aiBackoffMax     = 60 * time.Second
+
+	// aiQueueSize is the number of pages that can wait for an AI worker
+	// before submitting blocks
+	aiQueueSize = 1000
+)
+
+// aiJob is a stored page waiting for AI output
+type aiJob struct {
+	link     database.Link
+	content  string // loaded from storage while the job runs
+	attempts int
+	usage    ai.Usage
+	reserved float64 // budget set aside while the job runs
+}
+
+// retryQueue runs delayed retries in the background so workers are free to
+// keep fetching while AI calls back off
+type retryQueue struct {
+	wg sync.WaitGroup
+}
+
+// schedule runs fn after delay
+func (q *retryQueue) schedule(delay time.Duration, fn func()) {
+	q.wg.Add(1)
+	time.AfterFunc(delay, func() {
+		defer q.wg.Done()
+		fn()
+	})
+}
+
+// wait blocks until every scheduled retry has run
+func (q *retryQueue) wait() {
+	q.wg.Wait()
+}
+
+// aiBackoff returns the exponential backoff before the given retry
+func aiBackoff(attempts int) time.Duration {
+	backoff := aiBackoffInitial << attempts
+	if backoff > aiBackoffMax || backoff <= 0 {
+		backoff = aiBackoffMax
+	}
+	return backoff
+}
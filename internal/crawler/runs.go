@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"strings"
+	"time"
+
+	"stripper/internal/database"
+	"stripper/internal/tui"
+)
+
+// startRun records the start of a crawl run. AI usage of the run is recorded
+// under its id.
+func (c *Crawler) startRun() error {
+	c.run = database.Run{
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+		Seeds:     []string{c.baseURL.String()},
+		Config:    c.config,
+	}
+
+	id, err := c.db.StartRun(c.run)
+	if err != nil {
+		return err
+	}
+	c.run.ID = id
+
+	if c.ai != nil {
+		c.ai.run = c.run.StartedAt.Format(time.RFC3339)
+		c.ai.runID = c.run.ID
+	}
+	return nil
+}
+
+// finishRun records the end of the crawl run with its counters and the
+// error it stopped with, if any
+func (c *Crawler) finishRun(runErr error) {
+	c.runMu.Lock()
+	run := c.run
+	c.runMu.Unlock()
+
+	run.FinishedAt = time.Now().UTC()
+	run.ExitReason = "completed"
+	if runErr != nil {
+		run.ExitReason = "failed"
+		run.Error = runErr.Error()
+	}
+	if c.ai != nil {
+		c.ai.usageMu.Lock()
+		run.PromptTokens = c.ai.usage.PromptTokens
+		run.CompletionTokens = c.ai.usage.CompletionTokens
+		run.Cost = c.ai.cost
+		c.ai.usageMu.Unlock()
+	}
+
+	if err := c.db.FinishRun(run); err != nil {
+		debugf("Error recording end of run %d: %v", run.ID, err)
+	}
+}
+
+// setLinkStatus updates the status of a link for this run and counts
+// failures
+func (c *Crawler) setLinkStatus(pageURL string, status string, err error) {
+	if status == "failed" {
+		c.runMu.Lock()
+		c.run.Failed++
+		c.runMu.Unlock()
+	}
+	if dbErr := c.db.UpdateLinkStatus(pageURL, status, c.run.ID, err); dbErr != nil {
+		debugf("Error updating status of %s: %v", pageURL, dbErr)
+	}
+}
+
+// countFetched counts a page fetched and stored in this run
+func (c *Crawler) countFetched(size int) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	c.run.Fetched++
+	c.run.Bytes += int64(size)
+}
+
+// countSkipped counts a page not refetched because it was crawled recently
+func (c *Crawler) countSkipped() {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	c.run.Skipped++
+}
+
+// setRedirected records where a link redirects to and, unless the redirect
+// leaves the crawled host or ends at an ignored extension, queues the final
+// URL at the link's depth. A final URL that is already queued or crawled is
+// not crawled again.
+func (c *Crawler) setRedirected(link database.Link, finalURL string, redirects []database.Redirect, err error) {
+	c.runMu.Lock()
+	c.run.Redirected++
+	c.runMu.Unlock()
+
+	if dbErr := c.db.SetRedirect(link.URL, finalURL, redirects, c.run.ID, err); dbErr != nil {
+		debugf("Error recording redirect of %s: %v", link.URL, dbErr)
+	}
+	if err != nil {
+		return
+	}
+	if shouldIgnoreURL(finalURL, c.ignore) {
+		debugf("Ignoring redirect target: %s", finalURL)
+		return
+	}
+	if dbErr := c.queueLink(finalURL, link.Depth); dbErr != nil {
+		debugf("Error queueing redirect target %s: %v", finalURL, dbErr)
+	}
+}
+
+// recordFetch records the metadata of a page fetch. doc is the stored page,
+// nil when the fetch failed.
+func (c *Crawler) recordFetch(visit *pageVisit, doc *database.PageDocument) {
+	fetch := database.Fetch{
+		URL:           visit.url,
+		RunID:         c.run.ID,
+		FetchedAt:     time.Now(),
+		HTTPStatus:    visit.status,
+		ContentType:   visit.contentType,
+		Size:          visit.size,
+		Duration:      visit.duration,
+		ReaderLatency: visit.readerLatency,
+	}
+	if doc != nil {
+		fetch.Title = doc.Title
+		fetch.WordCount = len(strings.Fields(doc.Body))
+	}
+	if err := c.db.SaveFetch(fetch); err != nil {
+		debugf("Error recording fetch of %s: %v", visit.url, err)
+	}
+
+	c.ui.Send(tui.FetchMsg{
+		URL:           fetch.URL,
+		Size:          fetch.Size,
+		Duration:      fetch.Duration,
+		ReaderLatency: fetch.ReaderLatency,
+	})
+}
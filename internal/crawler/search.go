@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+var (
+	// htmlSkipPattern matches elements whose text is not page content
+	htmlSkipPattern = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+
+	// htmlTagPattern matches any HTML tag
+	htmlTagPattern = regexp.MustCompile(`(?s)<[^>]+>`)
+
+	// htmlHeadingPattern matches HTML headings
+	htmlHeadingPattern = regexp.MustCompile(`(?is)<h[1-6][^>]*>(.*?)</h[1-6]>`)
+)
+
+// pageDocument extracts the searchable title, headings and body text of a
+// stored page
+func pageDocument(pageURL string, content string, format string, crawledAt time.Time) database.PageDocument {
+	content = storage.StripMetadata(content)
+	doc := database.PageDocument{
+		URL:         pageURL,
+		Title:       extractTitle(content),
+		ContentHash: ai.HashText(content),
+		CrawledAt:   crawledAt,
+	}
+
+	var headings []string
+	if format == "html" {
+		for _, m := range htmlHeadingPattern.FindAllStringSubmatch(content, -1) {
+			headings = append(headings, htmlText(m[1]))
+		}
+		doc.Body = htmlText(content)
+	} else {
+		for _, line := range strings.Split(content, "\n") {
+			if _, heading := markdownHeading(strings.TrimSpace(line)); heading != "" {
+				headings = append(headings, heading)
+			}
+		}
+		doc.Body = content
+	}
+	doc.Headings = strings.Join(headings, "\n")
+
+	return doc
+}
+
+// htmlText reduces HTML to its visible text
+func htmlText(s string) string {
+	s = htmlSkipPattern.ReplaceAllString(s, " ")
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// indexPage adds a saved page to the full-text index and returns the indexed
+// document
+func (c *Crawler) indexPage(pageURL string, content string) database.PageDocument {
+	doc := pageDocument(pageURL, content, c.format, time.Now())
+	if err := c.db.IndexPage(doc); err != nil {
+		debugf("Error indexing %s for search: %v", pageURL, err)
+	}
+	return doc
+}
+
+// IndexArchive rebuilds the full-text index from the stored pages of the
+// completed links, e.g. for archives crawled before pages were indexed on
+// save. It returns the number of pages indexed.
+func IndexArchive(db *database.DB, store storage.Storage, format string) (int, error) {
+	if db.SearchModule() == "" {
+		return 0, database.ErrSearchUnavailable
+	}
+
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return 0, fmt.Errorf("error listing completed links: %w", err)
+	}
+
+	indexed := 0
+	for _, link := range links {
+		content, err := store.Load(link.URL, format)
+		if err != nil {
+			debugf("Skipping %s for search: %v", link.URL, err)
+			continue
+		}
+		crawledAt := link.LastCrawled
+		if crawledAt.IsZero() {
+			crawledAt, _ = store.GetLastCrawled(link.URL)
+		}
+		if err := db.IndexPage(pageDocument(link.URL, content, format, crawledAt)); err != nil {
+			return indexed, err
+		}
+		indexed++
+	}
+	return indexed, nil
+}
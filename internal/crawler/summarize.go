@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"fmt"
+
+	"stripper/internal/database"
+	"stripper/internal/storage"
+)
+
+// SummarizeOptions configures an AI pass over an existing archive
+type SummarizeOptions struct {
+	OutputDir   string
+	Format      string
+	Patterns    []string // URL globs; all pages when empty
+	All         bool     // also reprocess pages that already have AI output
+	Parallelism int
+	AI          AIOptions
+}
+
+// Summarize runs the AI over stored pages without recrawling them. By default
+// only pages whose AI output is missing, pending or failed are processed, so
+// an interrupted pass can be resumed; All reprocesses every page, e.g. after
+// changing the prompt or model.
+func Summarize(db *database.DB, store storage.Storage, opts SummarizeOptions) (*AIReport, error) {
+	links, err := db.GetLinksByStatus("completed")
+	if err != nil {
+		return nil, fmt.Errorf("error listing completed links: %w", err)
+	}
+	seeds, err := db.GetSeedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("error listing seed URLs: %w", err)
+	}
+
+	stage, err := newAIStage(db, store, opts.Format, opts.OutputDir, seeds, opts.Parallelism, opts.AI)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		if !opts.All && link.AIStatus == "completed" {
+			continue
+		}
+		if len(opts.Patterns) > 0 {
+			if _, ok := includeRank(link.URL, opts.Patterns, nil); !ok {
+				continue
+			}
+		}
+
+		stage.submit(link)
+	}
+
+	report := stage.wait()
+	return &report, nil
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+// translationPath returns the file, relative to the output directory, the
+// translation of a page into a language is written to
+func translationPath(seeds []string, pageURL string, language string) string {
+	return path.Join("translations", language, AISummaryFilename(seedFor(seeds, pageURL), pageURL))
+}
+
+// translate translates a page into the target language and records its
+// translation status. Pages whose current content was already translated
+// are skipped.
+func (s *aiStage) translate(job *aiJob) error {
+	pageURL := job.link.URL
+	hash := ai.HashText(job.content)
+
+	existing, err := s.db.GetTranslation(pageURL, s.translateTo)
+	if err != nil {
+		return fmt.Errorf("error reading translation status: %w", err)
+	}
+	if existing != nil && existing.Status == "completed" && existing.ContentHash == hash {
+		return nil
+	}
+
+	record := database.Translation{
+		URL:         pageURL,
+		Language:    s.translateTo,
+		ContentHash: hash,
+		Path:        translationPath(s.seeds, pageURL, s.translateTo),
+	}
+
+	text, usage, err := s.client.Translate(job.content, s.translateTo)
+	job.usage.Add(usage)
+	if err == nil {
+		err = s.saveTranslation(pageURL, record.Path, text)
+	}
+	if err != nil {
+		record.Status = "failed"
+		if ai.IsRetryable(err) && job.attempts < maxAIRetries {
+			record.Status = "pending"
+		}
+		record.Error = err.Error()
+		s.db.SaveTranslation(record)
+		return err
+	}
+
+	record.Status = "completed"
+	if err := s.db.SaveTranslation(record); err != nil {
+		return fmt.Errorf("error recording translation: %w", err)
+	}
+	debugf("Translated %s into %s (%d chars)", pageURL, s.translateTo, len(text))
+	return nil
+}
+
+// saveTranslation writes a translated page with front matter naming its
+// source and language
+func (s *aiStage) saveTranslation(pageURL string, file string, text string) error {
+	fullPath := path.Join(s.outputDir, file)
+	if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("error creating translation directory: %w", err)
+	}
+
+	content := fmt.Sprintf("---\nsource: %s\nlanguage: %s\n---\n\n%s", pageURL, s.translateTo, text)
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("error saving translation: %w", err)
+	}
+	return nil
+}
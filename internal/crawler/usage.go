@@ -0,0 +1,193 @@
+package crawler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+	"stripper/internal/tui"
+)
+
+// recordUsage stores the AI usage of a page and updates the running totals.
+// Once the totals reach the budget, AI processing stops for the rest of the
+// run.
+func (s *aiStage) recordUsage(pageURL string, usage ai.Usage) {
+	cost := s.price.Cost(usage)
+	if usage.Total() > 0 {
+		if err := s.db.SaveAIUsage(database.AIUsage{
+			RunID:            s.runID,
+			Run:              s.run,
+			URL:              pageURL,
+			Provider:         s.client.Provider().Name(),
+			Model:            s.model,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			Cost:             cost,
+		}); err != nil {
+			debugf("Error recording AI usage for %s: %v", pageURL, err)
+		}
+	}
+
+	s.usageMu.Lock()
+	s.usage.Add(usage)
+	s.cost += cost
+	if s.budget > 0 && s.cost >= s.budget && !s.budgetExhausted {
+		s.budgetExhausted = true
+		debugf("AI budget of %.4f reached (spent %.4f), skipping AI for remaining pages", s.budget, s.cost)
+	}
+	msg := s.totals()
+	s.usageMu.Unlock()
+
+	if s.onUsage != nil {
+		s.onUsage(msg)
+	}
+}
+
+// totals returns the running totals; the caller must hold usageMu
+func (s *aiStage) totals() tui.UsageMsg {
+	return tui.UsageMsg{
+		PromptTokens:     s.usage.PromptTokens,
+		CompletionTokens: s.usage.CompletionTokens,
+		Cost:             s.cost,
+		Budget:           s.budget,
+		BudgetExhausted:  s.budgetExhausted,
+	}
+}
+
+// reserve sets aside the estimated cost of a page before its requests are
+// sent, so parallel workers cannot together spend past the budget. A page
+// whose cost does not fit in what is left of the budget is not reserved.
+func (s *aiStage) reserve(job *aiJob) bool {
+	if s.budget <= 0 {
+		return true
+	}
+	estimated := s.price.Cost(ai.Usage{PromptTokens: s.steps() * ai.EstimateTokens(job.content)})
+
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	if s.cost+s.reserved+estimated > s.budget {
+		return false
+	}
+	s.reserved += estimated
+	job.reserved = estimated
+	return true
+}
+
+// release returns the reservation of a page once its usage is recorded
+func (s *aiStage) release(job *aiJob) {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	s.reserved -= job.reserved
+	job.reserved = 0
+}
+
+// steps returns the number of AI requests a page takes
+func (s *aiStage) steps() int {
+	steps := 0
+	if s.mode != "translate" {
+		steps++
+	}
+	if s.tagging {
+		steps++
+	}
+	if s.translateTo != "" {
+		steps++
+	}
+	return steps
+}
+
+// overBudget reports whether the AI budget of this run has been spent
+func (s *aiStage) overBudget() bool {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	return s.budgetExhausted
+}
+
+// usageMeter records the AI usage of a command run outside a crawl, such as
+// embed, ask or digest, under a run of its own, and keeps the command within
+// the AI budget. The run is named by its start time; it has no crawl run id.
+type usageMeter struct {
+	db       *database.DB
+	run      string
+	provider string
+	prices   map[string]ai.Price
+	budget   float64
+
+	mu       sync.Mutex
+	usage    ai.Usage
+	cost     float64
+	reserved float64
+}
+
+// newUsageMeter creates a meter for requests made with client. With a
+// budget, every model the command uses needs a price.
+func newUsageMeter(db *database.DB, client *ai.Client, opts AIOptions, models ...string) (*usageMeter, error) {
+	if opts.Budget > 0 {
+		for _, model := range models {
+			if _, ok := opts.Prices[model]; !ok {
+				return nil, fmt.Errorf("an AI budget requires a price for model %s in crawler.ai.prices", model)
+			}
+		}
+	}
+
+	return &usageMeter{
+		db:       db,
+		run:      time.Now().UTC().Format(time.RFC3339),
+		provider: client.Provider().Name(),
+		prices:   opts.Prices,
+		budget:   opts.Budget,
+	}, nil
+}
+
+// reserve sets aside the cost of a request with the estimated prompt tokens
+// and returns it, or returns an error when the request would take the spend
+// of the run past the budget. The reservation is returned by record.
+func (m *usageMeter) reserve(model string, estimated int) (float64, error) {
+	if m.budget <= 0 {
+		return 0, nil
+	}
+	cost := m.prices[model].Cost(ai.Usage{PromptTokens: estimated})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cost+m.reserved+cost > m.budget {
+		return 0, fmt.Errorf("AI budget of %.4f reached (spent %.4f)", m.budget, m.cost)
+	}
+	m.reserved += cost
+	return cost, nil
+}
+
+// record stores the usage of a request made for pageURL, adds it to the
+// totals of the run and releases the cost reserved for the request
+func (m *usageMeter) record(pageURL string, model string, usage ai.Usage, reserved float64) {
+	cost := m.prices[model].Cost(usage)
+	m.mu.Lock()
+	m.usage.Add(usage)
+	m.cost += cost
+	m.reserved -= reserved
+	m.mu.Unlock()
+
+	if usage.Total() == 0 {
+		return
+	}
+	if err := m.db.SaveAIUsage(database.AIUsage{
+		Run:              m.run,
+		URL:              pageURL,
+		Provider:         m.provider,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             cost,
+	}); err != nil {
+		debugf("Error recording AI usage for %s: %v", pageURL, err)
+	}
+}
+
+// totals returns the usage and cost of the run so far
+func (m *usageMeter) totals() (ai.Usage, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage, m.cost
+}
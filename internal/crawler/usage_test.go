@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"stripper/internal/ai"
+	"stripper/internal/database"
+)
+
+func TestAIStageReservesBudget(t *testing.T) {
+	// Each page is estimated at 0.4 of a budget of 1, so only two fit at once
+	content := strings.Repeat("word ", 100)
+	s := &aiStage{
+		mode:   "summarize",
+		price:  ai.Price{Prompt: 0.4 * 1e6 / float64(ai.EstimateTokens(content))},
+		budget: 1,
+	}
+
+	first, second, third := &aiJob{content: content}, &aiJob{content: content}, &aiJob{content: content}
+	if !s.reserve(first) || !s.reserve(second) {
+		t.Fatal("pages within the budget were refused")
+	}
+	if s.reserve(third) {
+		t.Fatal("a page past the budget was reserved while two others are running")
+	}
+
+	s.release(first)
+	if !s.reserve(third) {
+		t.Error("a page that fits after a release was refused")
+	}
+
+	// Tagging sends a second request per page
+	s.release(second)
+	s.release(third)
+	s.tagging = true
+	if !s.reserve(first) || s.reserve(second) {
+		t.Error("pages were not reserved for both of their requests")
+	}
+}
+
+func TestUsageMeterReservesBudget(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "crawler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m := &usageMeter{
+		db:     db,
+		run:    "2024-05-01T10:00:00Z",
+		prices: map[string]ai.Price{"model": {Prompt: 0.4, Completion: 0.4}},
+		budget: 1,
+	}
+
+	first, err := m.reserve("model", 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.reserve("model", 1e6); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.reserve("model", 1e6); err == nil {
+		t.Fatal("a request past the budget was reserved while two others are outstanding")
+	}
+
+	// The usage replaces the reservation
+	m.record("", "model", ai.Usage{PromptTokens: 1e6, CompletionTokens: 1e6}, first)
+	if _, err := m.reserve("model", 1e6); err == nil {
+		t.Error("a request past the budget was reserved after the first request cost 0.8")
+	}
+	if usage, cost := m.totals(); usage.Total() != 2e6 || cost != 0.8 {
+		t.Errorf("totals = %+v, %v", usage, cost)
+	}
+}
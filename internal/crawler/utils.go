@@ -1,11 +1,22 @@
 package crawler
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+
+	"stripper/internal/storage"
+)
 
 // shouldIgnoreURL checks if a URL should be ignored based on its extension.
 // It handles URLs with query parameters and fragments, and ensures consistent
 // extension comparison by normalizing both the URL and extension format.
 func shouldIgnoreURL(urlStr string, ignoreExts []string) bool {
+	return hasExtension(urlStr, ignoreExts)
+}
+
+// hasExtension checks if a URL or path ends with one of the given extensions,
+// ignoring query parameters, fragments and case.
+func hasExtension(urlStr string, exts []string) bool {
 	// Remove query parameters and fragments
 	if idx := strings.IndexAny(urlStr, "?#"); idx != -1 {
 		urlStr = urlStr[:idx]
@@ -15,7 +26,7 @@ func shouldIgnoreURL(urlStr string, ignoreExts []string) bool {
 	lower := strings.ToLower(urlStr)
 
 	// Check each extension
-	for _, ext := range ignoreExts {
+	for _, ext := range exts {
 		// Ensure extension has a leading dot
 		ext = "." + strings.TrimPrefix(ext, ".")
 		if strings.HasSuffix(lower, ext) {
@@ -24,3 +35,53 @@ func shouldIgnoreURL(urlStr string, ignoreExts []string) bool {
 	}
 	return false
 }
+
+// globMatch reports whether s matches a glob pattern in which "*" matches any
+// run of characters, including "/", and "?" matches a single character.
+func globMatch(pattern string, s string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), s)
+	return err == nil && matched
+}
+
+// readerHeaderPrefixes are the metadata lines the Reader API puts in front of
+// page content
+var readerHeaderPrefixes = []string{"Title:", "URL Source:", "Published Time:", "Markdown Content:"}
+
+// pageBody strips the storage metadata header and the Reader API header from
+// stored content, leaving only the page body
+func pageBody(content string) string {
+	content = storage.StripMetadata(content)
+
+	lines := strings.Split(content, "\n")
+	start := 0
+	for start < len(lines) {
+		line := strings.TrimSpace(lines[start])
+		isHeader := line == ""
+		for _, prefix := range readerHeaderPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				isHeader = true
+				break
+			}
+		}
+		if !isHeader {
+			break
+		}
+		start++
+	}
+
+	return strings.Join(lines[start:], "\n")
+}
@@ -0,0 +1,33 @@
+package crawler
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "https://example.com/docs/a", true},
+		{"*/docs/*", "https://example.com/docs/a/b", true},
+		{"*/docs/*", "https://example.com/blog/a", false},
+		{"https://example.com/docs/*", "https://example.com/docs/", true},
+		{"https://example.com/docs/*", "https://example.com/docs", false},
+		{"*/page?", "https://example.com/page1", true},
+		{"*/page?", "https://example.com/page", false},
+		{"*/page?", "https://example.com/page12", false},
+		{"*.pdf", "https://example.com/a.pdf", true},
+		{"*.pdf", "https://example.com/apdf", false},
+		{"*/v1.0/*", "https://example.com/v1x0/a", false},
+		{"*?q=(a)", "https://example.com/search?q=(a)", true},
+		{"https://example.com/ü*", "https://example.com/über", true},
+		{"", "", true},
+		{"", "https://example.com/", false},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package database
 
 import (
 	"database/sql"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -10,30 +13,158 @@ import (
 
 // DB handles database operations
 type DB struct {
-	db *sql.DB
+	db  *sql.DB
+	fts string // full-text module of the search index, "" when unavailable
 }
 
 // Link represents a URL to be crawled
 type Link struct {
+	URL          string
+	LastCrawled  time.Time
+	Depth        int
+	Status       string // "pending", "completed", "failed", "redirected"
+	Error        string // empty string for no error
+	AIStatus     string // "", "pending", "completed", "failed"
+	AIError      string
+	FinalURL     string     // where the URL redirects to, when status is "redirected"
+	Redirects    []Redirect // redirects followed from the URL to FinalURL
+	DiscoveredAt time.Time  // when the link was first queued
+	Priority     float64    // static priority score used by the priority queue strategy
+}
+
+// Queue strategies, the order in which GetNextBatch returns pending links
+const (
+	StrategyBFS      = "bfs"      // shallowest first, then in discovery order
+	StrategyDFS      = "dfs"      // deepest first, most recently discovered first
+	StrategyPriority = "priority" // highest priority first, then breadth-first
+)
+
+// QueueOrder decides which pending links GetNextBatch returns first
+type QueueOrder struct {
+	Strategy      string  // StrategyBFS when empty
+	InboundWeight float64 // priority added for every page linking to a link, for StrategyPriority
+}
+
+// Redirect is one hop of a redirect chain: a URL and the redirect status it
+// answered with
+type Redirect struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+// Asset represents a downloaded file referenced by a crawled page
+type Asset struct {
+	URL          string
+	PageURL      string
+	Hash         string
+	Path         string // relative to the output directory
+	ContentType  string
+	Size         int64
+	DownloadedAt time.Time
+}
+
+// Extraction is structured data extracted from a page by the AI
+type Extraction struct {
+	URL         string
+	Data        string // JSON document
+	SchemaHash  string
+	Model       string
+	ExtractedAt time.Time
+}
+
+// Classification is the AI classification of a page
+type Classification struct {
+	URL          string
+	Tags         []string
+	ContentType  string
+	Language     string
+	Model        string
+	ClassifiedAt time.Time
+}
+
+// Translation tracks the translation of a page into one language
+type Translation struct {
+	URL          string
+	Language     string
+	Status       string // "pending", "completed", "failed"
+	Error        string
+	ContentHash  string // hash of the content that was translated
+	Path         string // relative to the output directory
+	TranslatedAt time.Time
+}
+
+// AICacheStat summarizes cached AI results for one provider and model
+type AICacheStat struct {
+	Provider string
+	Model    string
+	Entries  int
+	Hits     int
+	Bytes    int64
+}
+
+// AIUsage records the tokens an AI request consumed for one page in one run
+type AIUsage struct {
+	RunID            int64  // id of the crawl run, 0 for usage outside a crawl
+	Run              string // start time of the run
+	URL              string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// RunUsage totals the AI usage of one run
+type RunUsage struct {
+	RunID            int64 // id of the crawl run, 0 for usage outside a crawl
+	Run              string
+	Pages            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// Embedding is the vector of one chunk of a stored page
+type Embedding struct {
 	URL         string
-	LastCrawled time.Time
-	Depth       int
-	Status      string // "pending", "completed", "failed"
-	Error       string // empty string for no error
+	Chunk       int    // position of the chunk in the page
+	Heading     string // heading trail the chunk belongs to
+	Content     string
+	ContentHash string // hash of the whole page the chunk was cut from
+	Model       string
+	Vector      []float32
 }
 
-// New creates a new database connection and initializes tables
+// New opens the database, applies pending schema migrations and prepares the
+// search index
 func New(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	d, err := Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("error opening database: %w", err)
+		return nil, err
 	}
 
-	if err := initTables(db); err != nil {
-		db.Close()
+	if _, err := d.Migrate(); err != nil {
+		d.Close()
 		return nil, err
 	}
 
+	fts, err := initSearch(d.db)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	d.fts = fts
+
+	return d, nil
+}
+
+// Open opens the database without changing its schema, for inspecting and
+// migrating it
+func Open(dbPath string) (*DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
 	return &DB{db: db}, nil
 }
 
@@ -42,67 +173,159 @@ func (d *DB) Close() error {
 	return d.db.Close()
 }
 
-// initTables creates the necessary database tables
-func initTables(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS links (
-			url TEXT PRIMARY KEY,
-			last_crawled DATETIME,
-			depth INTEGER,
-			status TEXT,
-			error TEXT
-		);
-		CREATE INDEX IF NOT EXISTS idx_status ON links(status);
-		CREATE INDEX IF NOT EXISTS idx_last_crawled ON links(last_crawled);
-	`)
-	return err
-}
-
-// QueueLink adds a link to the database
-func (d *DB) QueueLink(url string, depth int) error {
+// QueueLink adds a link to the database. A link that is already queued keeps
+// its depth and discovery time, but takes the new priority.
+func (d *DB) QueueLink(url string, depth int, priority float64) error {
 	_, err := d.db.Exec(`
-		INSERT OR IGNORE INTO links (url, depth, status)
-		VALUES (?, ?, 'pending')
-	`, url, depth)
+		INSERT INTO links (url, depth, status, discovered_at, priority)
+		VALUES (?, ?, 'pending', ?, ?)
+		ON CONFLICT(url) DO UPDATE SET priority = excluded.priority
+	`, url, depth, time.Now().UTC(), priority)
 	return err
 }
 
-// GetNextBatch returns a batch of pending links
-func (d *DB) GetNextBatch(batchSize int) ([]Link, error) {
+// GetNextBatch returns a batch of pending links in the order of the queue
+// strategy. Links queued before discovery times were recorded come first
+// among links of the same depth.
+func (d *DB) GetNextBatch(batchSize int, order QueueOrder) ([]Link, error) {
+	var orderBy string
+	var args []interface{}
+	switch order.Strategy {
+	case "", StrategyBFS:
+		orderBy = `depth, discovered_at, rowid`
+	case StrategyDFS:
+		orderBy = `depth DESC, discovered_at DESC, rowid DESC`
+	case StrategyPriority:
+		orderBy = `priority + ? * (
+				SELECT COUNT(DISTINCT e.source)
+				FROM edges e
+				WHERE e.target = links.url AND e.kind = 'link' AND e.source != links.url
+			) DESC, depth, discovered_at, rowid`
+		args = append(args, order.InboundWeight)
+	default:
+		return nil, fmt.Errorf("unsupported queue strategy: %s", order.Strategy)
+	}
+	args = append(args, batchSize)
+
 	rows, err := d.db.Query(`
-		SELECT 
-			url,
-			last_crawled,
-			depth,
-			status,
-			COALESCE(error, '') as error
+		SELECT `+linkColumns+`
 		FROM links
 		WHERE status = 'pending'
+		ORDER BY `+orderBy+`
 		LIMIT ?
-	`, batchSize)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLinks(rows)
+}
+
+// GetLinks returns all links, ordered by URL
+func (d *DB) GetLinks() ([]Link, error) {
+	rows, err := d.db.Query(`
+		SELECT ` + linkColumns + `
+		FROM links
+		ORDER BY url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLinks(rows)
+}
+
+// GetLinksByStatus returns all links with the given status, ordered by URL
+func (d *DB) GetLinksByStatus(status string) ([]Link, error) {
+	rows, err := d.db.Query(`
+		SELECT `+linkColumns+`
+		FROM links
+		WHERE status = ?
+		ORDER BY url
+	`, status)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanLinks(rows)
+}
+
+// GetLink returns a single link, or nil if it is not known
+func (d *DB) GetLink(url string) (*Link, error) {
+	rows, err := d.db.Query(`
+		SELECT `+linkColumns+`
+		FROM links
+		WHERE url = ?
+	`, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links, err := scanLinks(rows)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	return &links[0], nil
+}
+
+// linkColumns selects the columns read by scanLinks
+const linkColumns = `url, last_crawled, depth, status, COALESCE(error, ''),
+			COALESCE(ai_status, ''), COALESCE(ai_error, ''), COALESCE(final_url, ''), COALESCE(redirects, ''),
+			discovered_at, COALESCE(priority, 0)`
+
+// scanLinks reads link rows selected with linkColumns
+func scanLinks(rows *sql.Rows) ([]Link, error) {
 	var links []Link
 	for rows.Next() {
 		var link Link
 		var lastCrawled sql.NullTime
-		err := rows.Scan(&link.URL, &lastCrawled, &link.Depth, &link.Status, &link.Error)
+		var redirects string
+		var discoveredAt sql.NullTime
+		err := rows.Scan(&link.URL, &lastCrawled, &link.Depth, &link.Status, &link.Error, &link.AIStatus, &link.AIError, &link.FinalURL, &redirects,
+			&discoveredAt, &link.Priority)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
+		if redirects != "" {
+			if err := json.Unmarshal([]byte(redirects), &link.Redirects); err != nil {
+				return nil, fmt.Errorf("error decoding redirects of %s: %w", link.URL, err)
+			}
+		}
 		if lastCrawled.Valid {
 			link.LastCrawled = lastCrawled.Time
 		}
+		link.DiscoveredAt = discoveredAt.Time
 		links = append(links, link)
 	}
-	return links, nil
+	return links, rows.Err()
 }
 
-// UpdateLinkStatus updates the status of a link
-func (d *DB) UpdateLinkStatus(url string, status string, err error) error {
+// GetSeedURLs returns the URLs crawls were started from (depth 0)
+func (d *DB) GetSeedURLs() ([]string, error) {
+	rows, err := d.db.Query(`SELECT url FROM links WHERE depth = 0 ORDER BY url`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// UpdateLinkStatus updates the status of a link and tags it with the crawl
+// run that updated it
+func (d *DB) UpdateLinkStatus(url string, status string, runID int64, err error) error {
 	errMsg := ""
 	if err != nil {
 		errMsg = err.Error()
@@ -110,9 +333,30 @@ func (d *DB) UpdateLinkStatus(url string, status string, err error) error {
 
 	_, dbErr := d.db.Exec(`
 		UPDATE links
-		SET status = ?, error = ?, last_crawled = CURRENT_TIMESTAMP
+		SET status = ?, error = ?, last_crawled = CURRENT_TIMESTAMP, run_id = ?, final_url = NULL, redirects = NULL
 		WHERE url = ?
-	`, status, errMsg, url)
+	`, status, errMsg, runID, url)
+	return dbErr
+}
+
+// SetRedirect marks a link as redirected and records its redirect chain and
+// final URL. err explains why the final URL is not crawled, if it is not.
+func (d *DB) SetRedirect(url string, finalURL string, redirects []Redirect, runID int64, err error) error {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	chain, jsonErr := json.Marshal(redirects)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	_, dbErr := d.db.Exec(`
+		UPDATE links
+		SET status = 'redirected', error = ?, last_crawled = CURRENT_TIMESTAMP, run_id = ?, final_url = ?, redirects = ?
+		WHERE url = ?
+	`, errMsg, runID, finalURL, string(chain), url)
 	return dbErr
 }
 
@@ -143,15 +387,447 @@ func (d *DB) ShouldRecrawl(url string, force bool, minAge time.Duration) (bool,
 	return time.Since(lastCrawled.Time) > minAge, nil
 }
 
+// UpdateAIStatus updates the AI processing status of a link
+func (d *DB) UpdateAIStatus(url string, status string, err error) error {
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	_, dbErr := d.db.Exec(`
+		UPDATE links
+		SET ai_status = ?, ai_error = ?
+		WHERE url = ?
+	`, status, errMsg, url)
+	return dbErr
+}
+
+// GetAIStats counts completed links by AI status; links never sent to the AI
+// are counted under the empty status
+func (d *DB) GetAIStats() (map[string]int, error) {
+	rows, err := d.db.Query(`
+		SELECT COALESCE(ai_status, ''), COUNT(*)
+		FROM links
+		WHERE status = 'completed'
+		GROUP BY COALESCE(ai_status, '')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		stats[status] = count
+	}
+	return stats, rows.Err()
+}
+
 // GetStats returns crawling statistics
-func (d *DB) GetStats() (total, pending, completed, failed int, err error) {
+func (d *DB) GetStats() (total, pending, completed, failed, redirected int, err error) {
 	err = d.db.QueryRow(`
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) as pending,
-			SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) as completed,
-			SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failed
+			COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0) as pending,
+			COALESCE(SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END), 0) as completed,
+			COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0) as failed,
+			COALESCE(SUM(CASE WHEN status = 'redirected' THEN 1 ELSE 0 END), 0) as redirected
 		FROM links
-	`).Scan(&total, &pending, &completed, &failed)
+	`).Scan(&total, &pending, &completed, &failed, &redirected)
 	return
 }
+
+// SaveAsset records a downloaded asset
+func (d *DB) SaveAsset(asset Asset) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO assets (url, page_url, hash, path, content_type, size, downloaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, asset.URL, asset.PageURL, asset.Hash, asset.Path, asset.ContentType, asset.Size)
+	return err
+}
+
+// GetAsset returns the asset downloaded from the given URL, or nil if it
+// has not been downloaded yet
+func (d *DB) GetAsset(url string) (*Asset, error) {
+	var asset Asset
+	var downloadedAt sql.NullTime
+	err := d.db.QueryRow(`
+		SELECT url, COALESCE(page_url, ''), hash, path, COALESCE(content_type, ''), size, downloaded_at
+		FROM assets
+		WHERE url = ?
+	`, url).Scan(&asset.URL, &asset.PageURL, &asset.Hash, &asset.Path, &asset.ContentType, &asset.Size, &downloadedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if downloadedAt.Valid {
+		asset.DownloadedAt = downloadedAt.Time
+	}
+	return &asset, nil
+}
+
+// SaveExtraction stores the structured data extracted from a page
+func (d *DB) SaveExtraction(extraction Extraction) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO extractions (url, data, schema_hash, model, extracted_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, extraction.URL, extraction.Data, extraction.SchemaHash, extraction.Model)
+	return err
+}
+
+// GetExtractions returns all stored extractions, ordered by URL
+func (d *DB) GetExtractions() ([]Extraction, error) {
+	rows, err := d.db.Query(`
+		SELECT url, data, COALESCE(schema_hash, ''), COALESCE(model, ''), extracted_at
+		FROM extractions
+		ORDER BY url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extractions []Extraction
+	for rows.Next() {
+		var e Extraction
+		var extractedAt sql.NullTime
+		if err := rows.Scan(&e.URL, &e.Data, &e.SchemaHash, &e.Model, &extractedAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if extractedAt.Valid {
+			e.ExtractedAt = extractedAt.Time
+		}
+		extractions = append(extractions, e)
+	}
+	return extractions, rows.Err()
+}
+
+// SaveClassification stores the classification of a page, replacing its
+// previous tags
+func (d *DB) SaveClassification(c Classification) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO classifications (url, content_type, language, model, classified_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, c.URL, c.ContentType, c.Language, c.Model); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM page_tags WHERE url = ?`, c.URL); err != nil {
+		return err
+	}
+	for _, tag := range c.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO page_tags (url, tag) VALUES (?, ?)`, c.URL, tag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetClassifications returns the classifications of all pages keyed by URL
+func (d *DB) GetClassifications() (map[string]Classification, error) {
+	rows, err := d.db.Query(`
+		SELECT url, COALESCE(content_type, ''), COALESCE(language, ''), COALESCE(model, ''), classified_at
+		FROM classifications
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classifications := make(map[string]Classification)
+	for rows.Next() {
+		var c Classification
+		var classifiedAt sql.NullTime
+		if err := rows.Scan(&c.URL, &c.ContentType, &c.Language, &c.Model, &classifiedAt); err != nil {
+			return nil, err
+		}
+		c.ClassifiedAt = classifiedAt.Time
+		classifications[c.URL] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagRows, err := d.db.Query(`SELECT url, tag FROM page_tags ORDER BY url, rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var url, tag string
+		if err := tagRows.Scan(&url, &tag); err != nil {
+			return nil, err
+		}
+		if c, ok := classifications[url]; ok {
+			c.Tags = append(c.Tags, tag)
+			classifications[url] = c
+		}
+	}
+	return classifications, tagRows.Err()
+}
+
+// SaveTranslation records the translation status of a page
+func (d *DB) SaveTranslation(t Translation) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO translations (url, language, status, error, content_hash, path, translated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, t.URL, t.Language, t.Status, t.Error, t.ContentHash, t.Path)
+	return err
+}
+
+// GetTranslation returns the translation of a page into a language, or nil
+// if it was never translated
+func (d *DB) GetTranslation(url string, language string) (*Translation, error) {
+	t := Translation{URL: url, Language: language}
+	var translatedAt sql.NullTime
+	err := d.db.QueryRow(`
+		SELECT COALESCE(status, ''), COALESCE(error, ''), COALESCE(content_hash, ''), COALESCE(path, ''), translated_at
+		FROM translations
+		WHERE url = ? AND language = ?
+	`, url, language).Scan(&t.Status, &t.Error, &t.ContentHash, &t.Path, &translatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.TranslatedAt = translatedAt.Time
+	return &t, nil
+}
+
+// GetTranslationStats counts translations by language and status
+func (d *DB) GetTranslationStats() (map[string]map[string]int, error) {
+	rows, err := d.db.Query(`
+		SELECT language, status, COUNT(*)
+		FROM translations
+		GROUP BY language, status
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]map[string]int)
+	for rows.Next() {
+		var language, status string
+		var count int
+		if err := rows.Scan(&language, &status, &count); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		if stats[language] == nil {
+			stats[language] = make(map[string]int)
+		}
+		stats[language][status] = count
+	}
+	return stats, rows.Err()
+}
+
+// GetAICache returns a cached AI result and records the hit
+func (d *DB) GetAICache(contentHash, model, promptHash, provider string) (string, bool, error) {
+	var result string
+	err := d.db.QueryRow(`
+		SELECT result
+		FROM ai_cache
+		WHERE content_hash = ? AND model = ? AND prompt_hash = ? AND provider = ?
+	`, contentHash, model, promptHash, provider).Scan(&result)
+
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	_, err = d.db.Exec(`
+		UPDATE ai_cache
+		SET hits = hits + 1, last_hit = CURRENT_TIMESTAMP
+		WHERE content_hash = ? AND model = ? AND prompt_hash = ? AND provider = ?
+	`, contentHash, model, promptHash, provider)
+	return result, true, err
+}
+
+// PutAICache stores an AI result
+func (d *DB) PutAICache(contentHash, model, promptHash, provider, result string) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO ai_cache (content_hash, model, prompt_hash, provider, result, created_at, hits)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, 0)
+	`, contentHash, model, promptHash, provider, result)
+	return err
+}
+
+// GetAICacheStats returns cache statistics grouped by provider and model
+func (d *DB) GetAICacheStats() ([]AICacheStat, error) {
+	rows, err := d.db.Query(`
+		SELECT provider, model, COUNT(*), COALESCE(SUM(hits), 0), COALESCE(SUM(LENGTH(result)), 0)
+		FROM ai_cache
+		GROUP BY provider, model
+		ORDER BY provider, model
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []AICacheStat
+	for rows.Next() {
+		var s AICacheStat
+		if err := rows.Scan(&s.Provider, &s.Model, &s.Entries, &s.Hits, &s.Bytes); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// PurgeAICache deletes cached AI results created before the given time
+// (all results when before is zero), optionally limited to one model.
+// It returns the number of deleted entries.
+func (d *DB) PurgeAICache(before time.Time, model string) (int64, error) {
+	query := `DELETE FROM ai_cache WHERE 1 = 1`
+	var args []interface{}
+	if !before.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, before.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if model != "" {
+		query += ` AND model = ?`
+		args = append(args, model)
+	}
+
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SaveAIUsage records the AI usage of a page
+func (d *DB) SaveAIUsage(usage AIUsage) error {
+	_, err := d.db.Exec(`
+		INSERT INTO ai_usage (run_id, run, url, provider, model, prompt_tokens, completion_tokens, cost, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, usage.RunID, usage.Run, usage.URL, usage.Provider, usage.Model, usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+	return err
+}
+
+// GetAIUsageByRun returns the AI usage totals of each run, oldest first.
+// Usage of a crawl run is grouped by its id, usage outside a crawl by the
+// start time of its run.
+func (d *DB) GetAIUsageByRun() ([]RunUsage, error) {
+	rows, err := d.db.Query(`
+		SELECT COALESCE(run_id, 0), MIN(run), COUNT(DISTINCT url), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost)
+		FROM ai_usage
+		GROUP BY COALESCE(run_id, 0), CASE WHEN run_id > 0 THEN '' ELSE run END
+		ORDER BY MIN(run)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []RunUsage
+	for rows.Next() {
+		var r RunUsage
+		if err := rows.Scan(&r.RunID, &r.Run, &r.Pages, &r.PromptTokens, &r.CompletionTokens, &r.Cost); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// ReplaceEmbeddings replaces the stored chunks of a page
+func (d *DB) ReplaceEmbeddings(url string, embeddings []Embedding) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM embeddings WHERE url = ?`, url); err != nil {
+		return err
+	}
+	for _, e := range embeddings {
+		if _, err := tx.Exec(`
+			INSERT INTO embeddings (url, chunk, heading, content, content_hash, model, vector)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, url, e.Chunk, e.Heading, e.Content, e.ContentHash, e.Model, encodeVector(e.Vector)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEmbeddedHash returns the content hash and model a page was last
+// embedded with, or empty strings if it has not been embedded
+func (d *DB) GetEmbeddedHash(url string) (contentHash string, model string, err error) {
+	err = d.db.QueryRow(`
+		SELECT content_hash, model
+		FROM embeddings
+		WHERE url = ?
+		LIMIT 1
+	`, url).Scan(&contentHash, &model)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return contentHash, model, err
+}
+
+// GetEmbeddings returns every stored chunk embedded with the given model
+func (d *DB) GetEmbeddings(model string) ([]Embedding, error) {
+	rows, err := d.db.Query(`
+		SELECT url, chunk, COALESCE(heading, ''), content, content_hash, model, vector
+		FROM embeddings
+		WHERE model = ?
+		ORDER BY url, chunk
+	`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var embeddings []Embedding
+	for rows.Next() {
+		var e Embedding
+		var vector []byte
+		if err := rows.Scan(&e.URL, &e.Chunk, &e.Heading, &e.Content, &e.ContentHash, &e.Model, &vector); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		e.Vector = decodeVector(vector)
+		embeddings = append(embeddings, e)
+	}
+	return embeddings, rows.Err()
+}
+
+// encodeVector stores a vector as little-endian float32 values
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+// decodeVector reads a vector written by encodeVector
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestDB creates an empty, fully migrated database
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	d, err := New(filepath.Join(t.TempDir(), "crawler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestGetNextBatchOrder(t *testing.T) {
+	d := newTestDB(t)
+
+	// Queued in discovery order: url, depth, priority
+	queued := []struct {
+		url      string
+		depth    int
+		priority float64
+	}{
+		{"https://example.com/", 0, 0},
+		{"https://example.com/a", 1, 0},
+		{"https://example.com/b", 1, 2},
+		{"https://example.com/a/1", 2, 5},
+		{"https://example.com/c", 1, 0},
+		{"https://example.com/b/1", 2, 0},
+	}
+	for _, l := range queued {
+		if err := d.QueueLink(l.url, l.depth, l.priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.UpdateLinkStatus("https://example.com/", "completed", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []string{"https://example.com/", "https://example.com/a", "https://example.com/b"} {
+		if err := d.SaveEdge(Edge{Source: source, Target: "https://example.com/c", Kind: "link"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A page linking to itself does not raise its priority
+	if err := d.SaveEdge(Edge{Source: "https://example.com/b/1", Target: "https://example.com/b/1", Kind: "link"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		order QueueOrder
+		limit int
+		want  []string
+	}{
+		{
+			name:  "default is breadth-first",
+			order: QueueOrder{},
+			limit: 10,
+			want: []string{
+				"https://example.com/a", "https://example.com/b", "https://example.com/c",
+				"https://example.com/a/1", "https://example.com/b/1",
+			},
+		},
+		{
+			name:  "depth-first",
+			order: QueueOrder{Strategy: StrategyDFS},
+			limit: 10,
+			want: []string{
+				"https://example.com/b/1", "https://example.com/a/1",
+				"https://example.com/c", "https://example.com/b", "https://example.com/a",
+			},
+		},
+		{
+			name:  "priority",
+			order: QueueOrder{Strategy: StrategyPriority},
+			limit: 10,
+			want: []string{
+				"https://example.com/a/1", "https://example.com/b",
+				"https://example.com/a", "https://example.com/c", "https://example.com/b/1",
+			},
+		},
+		{
+			name:  "priority with inbound links",
+			order: QueueOrder{Strategy: StrategyPriority, InboundWeight: 2},
+			limit: 10,
+			want: []string{
+				"https://example.com/c", "https://example.com/a/1",
+				"https://example.com/b", "https://example.com/a", "https://example.com/b/1",
+			},
+		},
+		{
+			name:  "limit",
+			order: QueueOrder{Strategy: StrategyBFS},
+			limit: 2,
+			want:  []string{"https://example.com/a", "https://example.com/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		links, err := d.GetNextBatch(tt.limit, tt.order)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, l := range links {
+			got = append(got, l.URL)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := d.GetNextBatch(10, QueueOrder{Strategy: "random"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
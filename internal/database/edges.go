@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Edge is a link or redirect from one page to another
+type Edge struct {
+	Source       string
+	Target       string
+	Kind         string // "link" or "redirect"
+	Anchor       string // link text
+	Rel          string // rel attribute of the link
+	DiscoveredAt time.Time
+}
+
+// BrokenLink is a link to a page that could not be fetched
+type BrokenLink struct {
+	Source string
+	Target string
+	Anchor string
+	Error  string
+}
+
+// InboundCount is the number of pages linking to a page
+type InboundCount struct {
+	URL     string
+	Status  string
+	Inbound int
+}
+
+// SaveEdge records a link or redirect, replacing an earlier record of the
+// same edge
+func (d *DB) SaveEdge(edge Edge) error {
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO edges (source, target, kind, anchor, rel, discovered_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, edge.Source, edge.Target, edge.Kind, edge.Anchor, edge.Rel, time.Now().UTC())
+	return err
+}
+
+// DeleteEdgesFrom removes the edges of one kind leaving a page, before the
+// page is scanned again
+func (d *DB) DeleteEdgesFrom(source string, kind string) error {
+	_, err := d.db.Exec(`DELETE FROM edges WHERE source = ? AND kind = ?`, source, kind)
+	return err
+}
+
+// GetEdges returns the edges of one kind, or all edges when kind is empty
+func (d *DB) GetEdges(kind string) ([]Edge, error) {
+	rows, err := d.db.Query(`
+		SELECT source, target, kind, COALESCE(anchor, ''), COALESCE(rel, ''), discovered_at
+		FROM edges
+		WHERE ? = '' OR kind = ?
+		ORDER BY source, target
+	`, kind, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []Edge
+	for rows.Next() {
+		var e Edge
+		var discoveredAt sql.NullTime
+		if err := rows.Scan(&e.Source, &e.Target, &e.Kind, &e.Anchor, &e.Rel, &discoveredAt); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		e.DiscoveredAt = discoveredAt.Time
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// GetBrokenLinks returns the links pointing at pages whose fetch failed
+func (d *DB) GetBrokenLinks() ([]BrokenLink, error) {
+	rows, err := d.db.Query(`
+		SELECT e.source, e.target, COALESCE(e.anchor, ''), COALESCE(l.error, '')
+		FROM edges e
+		JOIN links l ON l.url = e.target
+		WHERE e.kind = 'link' AND l.status = 'failed'
+		ORDER BY e.target, e.source
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var broken []BrokenLink
+	for rows.Next() {
+		var b BrokenLink
+		if err := rows.Scan(&b.Source, &b.Target, &b.Anchor, &b.Error); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		broken = append(broken, b)
+	}
+	return broken, rows.Err()
+}
+
+// GetInboundCounts returns for every queued page the number of other pages
+// linking to it, most linked first
+func (d *DB) GetInboundCounts() ([]InboundCount, error) {
+	rows, err := d.db.Query(`
+		SELECT l.url, l.status, COUNT(DISTINCT e.source)
+		FROM links l
+		LEFT JOIN edges e ON e.target = l.url AND e.kind = 'link' AND e.source != l.url
+		GROUP BY l.url
+		ORDER BY COUNT(DISTINCT e.source) DESC, l.url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []InboundCount
+	for rows.Next() {
+		var c InboundCount
+		if err := rows.Scan(&c.URL, &c.Status, &c.Inbound); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
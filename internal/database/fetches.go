@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Fetch records one fetch of a page
+type Fetch struct {
+	URL           string
+	RunID         int64
+	FetchedAt     time.Time
+	HTTPStatus    int    // status of the site's response
+	ContentType   string // content type of the site's response
+	Size          int64  // size of the site's response body
+	Duration      time.Duration
+	ReaderLatency time.Duration // 0 when the page was not fetched through the Reader API
+	Title         string
+	WordCount     int
+}
+
+// FetchStats summarizes the fetches of a run
+type FetchStats struct {
+	Pages            int
+	Bytes            int64
+	Words            int
+	AvgDuration      time.Duration
+	AvgReaderLatency time.Duration
+}
+
+// SaveFetch records a page fetch
+func (d *DB) SaveFetch(f Fetch) error {
+	_, err := d.db.Exec(`
+		INSERT INTO fetches (url, run_id, fetched_at, http_status, content_type, size, duration_ms, reader_ms, title, word_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, f.URL, f.RunID, f.FetchedAt.UTC(), f.HTTPStatus, f.ContentType, f.Size,
+		f.Duration.Milliseconds(), f.ReaderLatency.Milliseconds(), f.Title, f.WordCount)
+	return err
+}
+
+const fetchColumns = `url, run_id, fetched_at, http_status, COALESCE(content_type, ''), size,
+	duration_ms, reader_ms, COALESCE(title, ''), word_count`
+
+// GetFetches returns the fetches of a page, most recent first
+func (d *DB) GetFetches(url string) ([]Fetch, error) {
+	rows, err := d.db.Query(`
+		SELECT `+fetchColumns+`
+		FROM fetches
+		WHERE url = ?
+		ORDER BY fetched_at DESC
+	`, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFetches(rows)
+}
+
+// GetSlowestFetches returns the fetches of a run that took longest, counting
+// both the site and the Reader API, slowest first
+func (d *DB) GetSlowestFetches(runID int64, limit int) ([]Fetch, error) {
+	rows, err := d.db.Query(`
+		SELECT `+fetchColumns+`
+		FROM fetches
+		WHERE run_id = ?
+		ORDER BY duration_ms + reader_ms DESC
+		LIMIT ?
+	`, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFetches(rows)
+}
+
+// GetFetchStats summarizes the fetches of a run
+func (d *DB) GetFetchStats(runID int64) (*FetchStats, error) {
+	var stats FetchStats
+	var avgDuration, avgReader sql.NullFloat64
+	err := d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(size), 0), COALESCE(SUM(word_count), 0),
+			AVG(duration_ms), AVG(CASE WHEN reader_ms > 0 THEN reader_ms END)
+		FROM fetches
+		WHERE run_id = ?
+	`, runID).Scan(&stats.Pages, &stats.Bytes, &stats.Words, &avgDuration, &avgReader)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.AvgDuration = time.Duration(avgDuration.Float64 * float64(time.Millisecond))
+	stats.AvgReaderLatency = time.Duration(avgReader.Float64 * float64(time.Millisecond))
+	return &stats, nil
+}
+
+// scanFetches reads fetch rows selected with fetchColumns
+func scanFetches(rows *sql.Rows) ([]Fetch, error) {
+	var fetches []Fetch
+	for rows.Next() {
+		var f Fetch
+		var fetchedAt sql.NullTime
+		var durationMS, readerMS int64
+		err := rows.Scan(&f.URL, &f.RunID, &fetchedAt, &f.HTTPStatus, &f.ContentType, &f.Size,
+			&durationMS, &readerMS, &f.Title, &f.WordCount)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		f.FetchedAt = fetchedAt.Time
+		f.Duration = time.Duration(durationMS) * time.Millisecond
+		f.ReaderLatency = time.Duration(readerMS) * time.Millisecond
+		fetches = append(fetches, f)
+	}
+	return fetches, rows.Err()
+}
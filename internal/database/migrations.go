@@ -0,0 +1,449 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Migration is one versioned change of the database schema
+type Migration struct {
+	Version     int
+	Description string
+	up          func(tx *sql.Tx) error
+}
+
+// AppliedMigration is a migration recorded in the schema_version table
+type AppliedMigration struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+// migrations lists every schema change in the order it is applied. Versions
+// must be consecutive; never edit a released migration, add a new one.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "initial schema",
+		up:          initialSchema,
+	},
+	{
+		Version:     2,
+		Description: "crawl runs",
+		up:          addRuns,
+	},
+	{
+		Version:     3,
+		Description: "link graph",
+		up:          addEdges,
+	},
+	{
+		Version:     4,
+		Description: "redirect tracking",
+		up:          addRedirects,
+	},
+	{
+		Version:     5,
+		Description: "fetch metadata",
+		up:          addFetches,
+	},
+	{
+		Version:     6,
+		Description: "queue order",
+		up:          addQueueOrder,
+	},
+	{
+		Version:     7,
+		Description: "page change tracking",
+		up:          addPageChanges,
+	},
+	{
+		Version:     8,
+		Description: "AI usage run ids",
+		up:          addAIUsageRunIDs,
+	},
+}
+
+// LatestSchemaVersion returns the schema version this build migrates to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// initSchemaVersion creates the table tracking applied migrations
+func initSchemaVersion(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			description TEXT,
+			applied_at DATETIME
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("error creating schema_version table: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the database schema, 0 for databases
+// created before schema versioning was introduced
+func (d *DB) SchemaVersion() (int, error) {
+	if err := initSchemaVersion(d.db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := d.db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// GetAppliedMigrations returns the migrations applied to the database, oldest
+// first
+func (d *DB) GetAppliedMigrations() ([]AppliedMigration, error) {
+	if err := initSchemaVersion(d.db); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`SELECT version, description, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&m.Version, &m.Description, &appliedAt); err != nil {
+			return nil, err
+		}
+		m.AppliedAt = appliedAt.Time
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// PendingMigrations returns the migrations not yet applied to the database
+func (d *DB) PendingMigrations() ([]Migration, error) {
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestSchemaVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than the latest version %d this build supports", version, LatestSchemaVersion())
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations in order, each in its own
+// transaction, and returns the migrations it applied
+func (d *DB) Migrate() ([]Migration, error) {
+	pending, err := d.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := d.apply(m); err != nil {
+			return applied, fmt.Errorf("error applying migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// apply runs one migration and records it in the same transaction, so a
+// failed migration leaves the schema unchanged
+func (d *DB) apply(m Migration) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Description, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// initialSchema creates the tables of databases created before schema
+// versioning. Archives from that time may already have any subset of them,
+// so every statement must be idempotent.
+func initialSchema(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS links (
+			url TEXT PRIMARY KEY,
+			last_crawled DATETIME,
+			depth INTEGER,
+			status TEXT,
+			error TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_status ON links(status);
+		CREATE INDEX IF NOT EXISTS idx_last_crawled ON links(last_crawled);
+		CREATE TABLE IF NOT EXISTS assets (
+			url TEXT PRIMARY KEY,
+			page_url TEXT,
+			hash TEXT,
+			path TEXT,
+			content_type TEXT,
+			size INTEGER,
+			downloaded_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_assets_hash ON assets(hash);
+		CREATE TABLE IF NOT EXISTS extractions (
+			url TEXT PRIMARY KEY,
+			data TEXT,
+			schema_hash TEXT,
+			model TEXT,
+			extracted_at DATETIME
+		);
+		CREATE TABLE IF NOT EXISTS classifications (
+			url TEXT PRIMARY KEY,
+			content_type TEXT,
+			language TEXT,
+			model TEXT,
+			classified_at DATETIME
+		);
+		CREATE TABLE IF NOT EXISTS page_tags (
+			url TEXT,
+			tag TEXT,
+			PRIMARY KEY (url, tag)
+		);
+		CREATE INDEX IF NOT EXISTS idx_page_tags_tag ON page_tags(tag);
+		CREATE TABLE IF NOT EXISTS translations (
+			url TEXT,
+			language TEXT,
+			status TEXT,
+			error TEXT,
+			content_hash TEXT,
+			path TEXT,
+			translated_at DATETIME,
+			PRIMARY KEY (url, language)
+		);
+		CREATE TABLE IF NOT EXISTS ai_cache (
+			content_hash TEXT,
+			model TEXT,
+			prompt_hash TEXT,
+			provider TEXT,
+			result TEXT,
+			created_at DATETIME,
+			hits INTEGER DEFAULT 0,
+			last_hit DATETIME,
+			PRIMARY KEY (content_hash, model, prompt_hash, provider)
+		);
+		CREATE TABLE IF NOT EXISTS ai_usage (
+			run TEXT,
+			url TEXT,
+			provider TEXT,
+			model TEXT,
+			prompt_tokens INTEGER,
+			completion_tokens INTEGER,
+			cost REAL,
+			recorded_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_ai_usage_run ON ai_usage(run);
+		CREATE TABLE IF NOT EXISTS embeddings (
+			url TEXT,
+			chunk INTEGER,
+			heading TEXT,
+			content TEXT,
+			content_hash TEXT,
+			model TEXT,
+			vector BLOB,
+			PRIMARY KEY (url, chunk)
+		);
+		CREATE INDEX IF NOT EXISTS idx_embeddings_model ON embeddings(model);
+		CREATE TABLE IF NOT EXISTS pages (
+			id INTEGER PRIMARY KEY,
+			url TEXT UNIQUE,
+			host TEXT,
+			path TEXT,
+			title TEXT,
+			crawled_at DATETIME,
+			content_hash TEXT,
+			added_at DATETIME,
+			changed_at DATETIME
+		);
+		CREATE INDEX IF NOT EXISTS idx_pages_host ON pages(host);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Columns added after the links table was first released
+	for _, column := range []struct{ name, def string }{
+		{"ai_status", "TEXT"},
+		{"ai_error", "TEXT"},
+	} {
+		if err := ensureColumn(tx, "links", column.name, column.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRuns records crawl runs and tags links with the run that last updated
+// their status
+func addRuns(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE runs (
+			id INTEGER PRIMARY KEY,
+			started_at DATETIME,
+			finished_at DATETIME,
+			seeds TEXT,
+			config TEXT,
+			exit_reason TEXT,
+			error TEXT,
+			fetched INTEGER DEFAULT 0,
+			skipped INTEGER DEFAULT 0,
+			failed INTEGER DEFAULT 0,
+			bytes INTEGER DEFAULT 0,
+			prompt_tokens INTEGER DEFAULT 0,
+			completion_tokens INTEGER DEFAULT 0,
+			cost REAL DEFAULT 0
+		);
+		ALTER TABLE links ADD COLUMN run_id INTEGER;
+		CREATE INDEX idx_links_run ON links(run_id);
+	`)
+	return err
+}
+
+// addEdges records the links and redirects between pages
+func addEdges(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE edges (
+			source TEXT,
+			target TEXT,
+			kind TEXT,
+			anchor TEXT,
+			rel TEXT,
+			discovered_at DATETIME,
+			PRIMARY KEY (source, target, kind)
+		);
+		CREATE INDEX idx_edges_target ON edges(target);
+	`)
+	return err
+}
+
+// addRedirects records where links redirect to and counts redirected links
+// per run
+func addRedirects(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE links ADD COLUMN final_url TEXT;
+		ALTER TABLE links ADD COLUMN redirects TEXT;
+		ALTER TABLE runs ADD COLUMN redirected INTEGER DEFAULT 0;
+	`)
+	return err
+}
+
+// addFetches records the response and timings of every page fetch
+func addFetches(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE fetches (
+			id INTEGER PRIMARY KEY,
+			url TEXT,
+			run_id INTEGER,
+			fetched_at DATETIME,
+			http_status INTEGER,
+			content_type TEXT,
+			size INTEGER,
+			duration_ms INTEGER,
+			reader_ms INTEGER,
+			title TEXT,
+			word_count INTEGER
+		);
+		CREATE INDEX idx_fetches_url ON fetches(url);
+		CREATE INDEX idx_fetches_run ON fetches(run_id);
+	`)
+	return err
+}
+
+// ensureColumn adds a column to an existing table if it is missing
+func ensureColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("error adding column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// addQueueOrder records when a link was discovered and its priority, so the
+// queue can be processed in a defined order
+func addQueueOrder(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE links ADD COLUMN discovered_at DATETIME;
+		ALTER TABLE links ADD COLUMN priority REAL DEFAULT 0;
+		CREATE INDEX idx_links_queue ON links(status, depth, discovered_at);
+	`)
+	return err
+}
+
+// addPageChanges adds the columns tracking when a page was first indexed and
+// when its content last changed to pages tables created before them. Newer
+// databases already have them from the initial schema.
+func addPageChanges(tx *sql.Tx) error {
+	for _, column := range []struct{ name, def string }{
+		{"content_hash", "TEXT"},
+		{"added_at", "DATETIME"},
+		{"changed_at", "DATETIME"},
+	} {
+		if err := ensureColumn(tx, "pages", column.name, column.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addAIUsageRunIDs tags AI usage with the id of the crawl run it was recorded
+// in. Usage recorded before, which only has the start time of its run, is
+// matched to the run that started at that time.
+func addAIUsageRunIDs(tx *sql.Tx) error {
+	if err := ensureColumn(tx, "ai_usage", "run_id", "INTEGER"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_ai_usage_run_id ON ai_usage(run_id);
+		UPDATE ai_usage SET run_id = (
+			SELECT MIN(id) FROM runs WHERE datetime(runs.started_at) = datetime(ai_usage.run)
+		)
+		WHERE run_id IS NULL;
+	`)
+	return err
+}
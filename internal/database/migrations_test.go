@@ -0,0 +1,240 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// baselineSchema is the database layout written before schema versioning,
+// with the pages table as first released
+const baselineSchema = `
+	CREATE TABLE links (
+		url TEXT PRIMARY KEY,
+		last_crawled DATETIME,
+		depth INTEGER,
+		status TEXT,
+		error TEXT
+	);
+	CREATE INDEX idx_status ON links(status);
+	CREATE INDEX idx_last_crawled ON links(last_crawled);
+	CREATE TABLE pages (
+		id INTEGER PRIMARY KEY,
+		url TEXT UNIQUE,
+		host TEXT,
+		path TEXT,
+		title TEXT,
+		crawled_at DATETIME
+	);
+	INSERT INTO links (url, depth, status) VALUES ('https://example.com/', 0, 'completed');
+	INSERT INTO links (url, depth, status) VALUES ('https://example.com/docs', 1, 'pending');
+`
+
+// columns returns the column names of a table
+func columns(t *testing.T, db *sql.DB, table string) map[string]bool {
+	t.Helper()
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func TestMigrateBaselineSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "crawler.db")
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	d, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	version, err := d.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, LatestSchemaVersion())
+	}
+
+	tests := []struct {
+		table  string
+		column string
+	}{
+		{"links", "ai_status"},
+		{"links", "ai_error"},
+		{"links", "final_url"},
+		{"links", "discovered_at"},
+		{"links", "priority"},
+		{"pages", "content_hash"},
+		{"pages", "added_at"},
+		{"pages", "changed_at"},
+		{"runs", "started_at"},
+		{"edges", "target"},
+		{"fetches", "url"},
+	}
+	for _, tt := range tests {
+		if !columns(t, d.db, tt.table)[tt.column] {
+			t.Errorf("table %s has no column %s", tt.table, tt.column)
+		}
+	}
+
+	links, err := d.GetLinks()
+	if err != nil {
+		t.Fatalf("GetLinks: %v", err)
+	}
+	if len(links) != 2 || links[0].Status != "completed" || links[1].Status != "pending" {
+		t.Errorf("links after migration = %+v", links)
+	}
+
+	if err := d.IndexPage(PageDocument{URL: "https://example.com/", Title: "Home", ContentHash: "h1"}); err != nil {
+		t.Errorf("IndexPage after migration: %v", err)
+	}
+
+	applied, err := d.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second Migrate applied %d migrations, want 0", len(applied))
+	}
+}
+
+func TestMigratePagesWithoutChangeTracking(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "crawler.db")
+	d, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Roll back to a version 6 database whose pages table predates change
+	// tracking
+	if _, err := d.db.Exec(`
+		DROP TABLE pages;
+		CREATE TABLE pages (
+			id INTEGER PRIMARY KEY,
+			url TEXT UNIQUE,
+			host TEXT,
+			path TEXT,
+			title TEXT,
+			crawled_at DATETIME
+		);
+		DELETE FROM schema_version WHERE version > 6;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	d, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	version, err := d.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("schema version = %d, want %d", version, LatestSchemaVersion())
+	}
+	for _, column := range []string{"content_hash", "added_at", "changed_at"} {
+		if !columns(t, d.db, "pages")[column] {
+			t.Errorf("table pages has no column %s", column)
+		}
+	}
+
+	crawledAt := time.Now().UTC()
+	if err := d.IndexPage(PageDocument{URL: "https://example.com/", Title: "Home", ContentHash: "h1", CrawledAt: crawledAt}); err != nil {
+		t.Fatalf("IndexPage: %v", err)
+	}
+	changes, err := d.GetChangedPages(crawledAt.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GetChangedPages: %v", err)
+	}
+	if len(changes) != 1 || !changes[0].New {
+		t.Errorf("changes = %+v, want the new page", changes)
+	}
+}
+
+func TestMigrateAIUsageRunIDs(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "crawler.db")
+	d, err := New(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	id, err := d.StartRun(Run{StartedAt: startedAt, Seeds: []string{"https://example.com/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Roll back to a version 7 database whose AI usage only names the start
+	// time of its run
+	if _, err := d.db.Exec(`
+		DROP TABLE ai_usage;
+		CREATE TABLE ai_usage (
+			run TEXT,
+			url TEXT,
+			provider TEXT,
+			model TEXT,
+			prompt_tokens INTEGER,
+			completion_tokens INTEGER,
+			cost REAL,
+			recorded_at DATETIME
+		);
+		INSERT INTO ai_usage VALUES
+			('2024-05-01T10:00:00Z', 'https://example.com/a', 'openai', 'gpt-4o-mini', 100, 10, 0.5, CURRENT_TIMESTAMP),
+			('2024-05-01T10:00:00Z', 'https://example.com/b', 'openai', 'gpt-4o-mini', 200, 20, 1, CURRENT_TIMESTAMP),
+			('2024-05-02T09:00:00Z', '', 'openai', 'gpt-4o-mini', 50, 5, 0.25, CURRENT_TIMESTAMP);
+		DELETE FROM schema_version WHERE version > 7;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	d, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	// Usage of a later crawl run is recorded under its id
+	if err := d.SaveAIUsage(AIUsage{RunID: id + 1, Run: "2024-05-03T08:00:00Z", URL: "https://example.com/a", PromptTokens: 10, Cost: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := d.GetAIUsageByRun()
+	if err != nil {
+		t.Fatalf("GetAIUsageByRun: %v", err)
+	}
+	want := []RunUsage{
+		{RunID: id, Run: "2024-05-01T10:00:00Z", Pages: 2, PromptTokens: 300, CompletionTokens: 30, Cost: 1.5},
+		{Run: "2024-05-02T09:00:00Z", Pages: 1, PromptTokens: 50, CompletionTokens: 5, Cost: 0.25},
+		{RunID: id + 1, Run: "2024-05-03T08:00:00Z", Pages: 1, PromptTokens: 10, Cost: 0.1},
+	}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("usage by run = %+v, want %+v", runs, want)
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Run records one crawl run
+type Run struct {
+	ID               int64
+	StartedAt        time.Time
+	FinishedAt       time.Time // zero while running or when interrupted
+	Seeds            []string
+	Config           string // JSON snapshot of the effective configuration
+	ExitReason       string // "completed", "failed", or "" when unfinished
+	Error            string
+	Fetched          int // pages fetched and stored
+	Skipped          int // pages not refetched because they were crawled recently
+	Failed           int // pages that failed to fetch or store
+	Redirected       int // links that redirect to another URL
+	Bytes            int64
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// StartRun records the start of a crawl run and returns its id
+func (d *DB) StartRun(run Run) (int64, error) {
+	seeds, err := json.Marshal(run.Seeds)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := d.db.Exec(`
+		INSERT INTO runs (started_at, seeds, config)
+		VALUES (?, ?, ?)
+	`, run.StartedAt.UTC(), string(seeds), run.Config)
+	if err != nil {
+		return 0, fmt.Errorf("error recording run: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// FinishRun records the end of a crawl run with its exit reason and counters
+func (d *DB) FinishRun(run Run) error {
+	_, err := d.db.Exec(`
+		UPDATE runs
+		SET finished_at = ?, exit_reason = ?, error = ?, fetched = ?, skipped = ?, failed = ?,
+			redirected = ?, bytes = ?, prompt_tokens = ?, completion_tokens = ?, cost = ?
+		WHERE id = ?
+	`, run.FinishedAt.UTC(), run.ExitReason, run.Error, run.Fetched, run.Skipped, run.Failed,
+		run.Redirected, run.Bytes, run.PromptTokens, run.CompletionTokens, run.Cost, run.ID)
+	return err
+}
+
+const runColumns = `id, started_at, finished_at, COALESCE(seeds, '[]'), COALESCE(config, ''),
+	COALESCE(exit_reason, ''), COALESCE(error, ''), fetched, skipped, failed, redirected, bytes,
+	prompt_tokens, completion_tokens, cost`
+
+// GetRuns returns all crawl runs, oldest first
+func (d *DB) GetRuns() ([]Run, error) {
+	rows, err := d.db.Query(`SELECT ` + runColumns + ` FROM runs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// GetRun returns a crawl run, or nil if there is none with the id
+func (d *DB) GetRun(id int64) (*Run, error) {
+	run, err := scanRun(d.db.QueryRow(`SELECT `+runColumns+` FROM runs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+// GetRunLinks returns the links whose status was last updated by a run
+func (d *DB) GetRunLinks(id int64) ([]Link, error) {
+	rows, err := d.db.Query(`
+		SELECT `+linkColumns+`
+		FROM links
+		WHERE run_id = ?
+		ORDER BY url
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLinks(rows)
+}
+
+// scanRun reads a run selected with runColumns
+func scanRun(row interface{ Scan(...interface{}) error }) (*Run, error) {
+	var run Run
+	var startedAt, finishedAt sql.NullTime
+	var seeds string
+	err := row.Scan(&run.ID, &startedAt, &finishedAt, &seeds, &run.Config,
+		&run.ExitReason, &run.Error, &run.Fetched, &run.Skipped, &run.Failed, &run.Redirected, &run.Bytes,
+		&run.PromptTokens, &run.CompletionTokens, &run.Cost)
+	if err != nil {
+		return nil, err
+	}
+	run.StartedAt = startedAt.Time
+	run.FinishedAt = finishedAt.Time
+	if err := json.Unmarshal([]byte(seeds), &run.Seeds); err != nil {
+		return nil, fmt.Errorf("error decoding seeds of run %d: %w", run.ID, err)
+	}
+	return &run, nil
+}
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrSearchUnavailable is returned by Search when the SQLite library lacks
+// the full-text module the index of this database was created with
+var ErrSearchUnavailable = errors.New("full-text search is not available in this build (rebuild with -tags sqlite_fts5)")
+
+// columnWeights ranks matches in the title above headings above the body
+var columnWeights = []float64{10, 5, 1}
+
+// PageDocument is the searchable text of a stored page
+type PageDocument struct {
+	URL         string
+	Title       string
+	Headings    string // one heading per line
+	Body        string
+	ContentHash string // hash of the stored content, used to detect changes
+	CrawledAt   time.Time
+}
+
+// PageChange is a page whose content was added or changed
+type PageChange struct {
+	URL       string
+	Title     string
+	New       bool // first indexed in the period rather than updated
+	ChangedAt time.Time
+}
+
+// SearchQuery describes a full-text search and its filters
+type SearchQuery struct {
+	Query       string
+	Host        string    // exact host; any when empty
+	PathPrefix  string    // URL path prefix; any when empty
+	Since       time.Time // crawled at or after; any when zero
+	Until       time.Time // crawled before; any when zero
+	Tag         string    // AI tag; any when empty
+	ContentType string    // AI content type; any when empty
+	Language    string    // AI-detected language; any when empty
+	Limit       int
+	AnyWord     bool      // match pages containing any word rather than all
+	Highlight   [2]string // markers placed around matches in snippets
+}
+
+// SearchHit is a page matching a full-text search
+type SearchHit struct {
+	URL       string
+	Title     string
+	Snippet   string
+	CrawledAt time.Time
+	Score     float64 // BM25, higher is better
+}
+
+// initSearch creates the full-text index and returns the FTS module it uses,
+// or "" when the index exists but its module is not compiled in. FTS5 is
+// used when available; FTS4 is always built into the driver and serves as
+// the fallback.
+func initSearch(db *sql.DB) (string, error) {
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return "", fmt.Errorf("error checking for FTS5: %w", err)
+	}
+
+	var schema string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'pages_fts'`).Scan(&schema)
+	switch {
+	case err == nil:
+		if strings.Contains(strings.ToLower(schema), "fts5") {
+			if !fts5 {
+				return "", nil
+			}
+			return "fts5", nil
+		}
+		return "fts4", nil
+	case err != sql.ErrNoRows:
+		return "", fmt.Errorf("error reading search index schema: %w", err)
+	}
+
+	module, create := "fts4", `CREATE VIRTUAL TABLE pages_fts USING fts4(title, headings, body, tokenize=porter)`
+	if fts5 {
+		module, create = "fts5", `CREATE VIRTUAL TABLE pages_fts USING fts5(title, headings, body, tokenize='porter unicode61')`
+	}
+	if _, err := db.Exec(create); err != nil {
+		return "", fmt.Errorf("error creating search index: %w", err)
+	}
+	return module, nil
+}
+
+// SearchModule returns the full-text module of the search index ("fts5" or
+// "fts4"), or "" when search is unavailable
+func (d *DB) SearchModule() string {
+	return d.fts
+}
+
+// IndexPage adds or replaces a page in the full-text index. The time a page
+// was first indexed and the last time its content hash changed are kept, so
+// GetChangedPages can report what changed between crawls.
+func (d *DB) IndexPage(doc PageDocument) error {
+	var host, path string
+	if u, err := url.Parse(doc.URL); err == nil {
+		host, path = u.Host, u.Path
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var crawledAt interface{}
+	if !doc.CrawledAt.IsZero() {
+		crawledAt = doc.CrawledAt.UTC()
+	}
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO pages (url, host, path, title, crawled_at, content_hash, added_at, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET host = excluded.host, path = excluded.path,
+			title = excluded.title, crawled_at = excluded.crawled_at,
+			changed_at = CASE WHEN pages.content_hash IS excluded.content_hash
+				THEN pages.changed_at ELSE excluded.changed_at END,
+			content_hash = excluded.content_hash
+		RETURNING id
+	`, doc.URL, host, path, doc.Title, crawledAt, doc.ContentHash, crawledAt, crawledAt).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("error indexing %s: %w", doc.URL, err)
+	}
+
+	if d.fts != "" {
+		if _, err := tx.Exec(`DELETE FROM pages_fts WHERE rowid = ?`, id); err != nil {
+			return fmt.Errorf("error indexing %s: %w", doc.URL, err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO pages_fts (rowid, title, headings, body) VALUES (?, ?, ?, ?)
+		`, id, doc.Title, doc.Headings, doc.Body); err != nil {
+			return fmt.Errorf("error indexing %s: %w", doc.URL, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetChangedPages returns the pages added or whose content changed at or
+// after since, ordered by URL
+func (d *DB) GetChangedPages(since time.Time) ([]PageChange, error) {
+	rows, err := d.db.Query(`
+		SELECT url, COALESCE(title, ''), added_at >= ?, changed_at
+		FROM pages
+		WHERE changed_at >= ?
+		ORDER BY url
+	`, since.UTC(), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []PageChange
+	for rows.Next() {
+		var c PageChange
+		var changedAt sql.NullTime
+		if err := rows.Scan(&c.URL, &c.Title, &c.New, &changedAt); err != nil {
+			return nil, err
+		}
+		c.ChangedAt = changedAt.Time
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// GetPageBody returns the indexed body text of a page
+func (d *DB) GetPageBody(pageURL string) (string, error) {
+	if d.fts == "" {
+		return "", ErrSearchUnavailable
+	}
+	var body string
+	err := d.db.QueryRow(`
+		SELECT f.body FROM pages p JOIN pages_fts f ON f.rowid = p.id WHERE p.url = ?
+	`, pageURL).Scan(&body)
+	return body, err
+}
+
+// Search runs a full-text query over the indexed pages. Words in the query
+// must all match unless AnyWord is set; a trailing * matches a prefix.
+func (d *DB) Search(q SearchQuery) ([]SearchHit, error) {
+	if d.fts == "" {
+		return nil, ErrSearchUnavailable
+	}
+	match := matchExpression(q.Query, q.AnyWord)
+	if match == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+
+	filters, args := "", []interface{}{match}
+	if q.Host != "" {
+		filters += " AND p.host = ?"
+		args = append(args, q.Host)
+	}
+	if q.PathPrefix != "" {
+		filters += " AND substr(p.path, 1, length(?)) = ?"
+		args = append(args, q.PathPrefix, q.PathPrefix)
+	}
+	if !q.Since.IsZero() {
+		filters += " AND p.crawled_at >= ?"
+		args = append(args, q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		filters += " AND p.crawled_at < ?"
+		args = append(args, q.Until.UTC())
+	}
+	if q.Tag != "" {
+		filters += " AND p.url IN (SELECT url FROM page_tags WHERE tag = ? COLLATE NOCASE)"
+		args = append(args, q.Tag)
+	}
+	if q.ContentType != "" {
+		filters += " AND p.url IN (SELECT url FROM classifications WHERE content_type = ? COLLATE NOCASE)"
+		args = append(args, q.ContentType)
+	}
+	if q.Language != "" {
+		filters += " AND p.url IN (SELECT url FROM classifications WHERE language = ? COLLATE NOCASE)"
+		args = append(args, q.Language)
+	}
+
+	if d.fts == "fts5" {
+		return d.searchFTS5(q, filters, args)
+	}
+	return d.searchFTS4(q, filters, args)
+}
+
+// searchFTS5 ranks with the built-in bm25 function
+func (d *DB) searchFTS5(q SearchQuery, filters string, args []interface{}) ([]SearchHit, error) {
+	limit := ""
+	if q.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+	args = append([]interface{}{q.Highlight[0], q.Highlight[1]}, args...)
+
+	rows, err := d.db.Query(fmt.Sprintf(`
+		SELECT p.url, p.title, p.crawled_at,
+			snippet(pages_fts, -1, ?, ?, '…', 24),
+			bm25(pages_fts, %g, %g, %g) AS score
+		FROM pages_fts JOIN pages p ON p.id = pages_fts.rowid
+		WHERE pages_fts MATCH ?`+filters+`
+		ORDER BY score`+limit,
+		columnWeights[0], columnWeights[1], columnWeights[2]), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var title sql.NullString
+		var crawledAt sql.NullTime
+		if err := rows.Scan(&hit.URL, &title, &crawledAt, &hit.Snippet, &hit.Score); err != nil {
+			return nil, err
+		}
+		hit.Title = title.String
+		hit.CrawledAt = crawledAt.Time
+		hit.Score = -hit.Score // bm25() is lower for better matches
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// searchFTS4 ranks in Go with BM25 computed from matchinfo, as FTS4 has no
+// ranking function of its own
+func (d *DB) searchFTS4(q SearchQuery, filters string, args []interface{}) ([]SearchHit, error) {
+	args = append([]interface{}{q.Highlight[0], q.Highlight[1]}, args...)
+
+	rows, err := d.db.Query(`
+		SELECT p.url, p.title, p.crawled_at,
+			snippet(pages_fts, ?, ?, '…', -1, 24),
+			matchinfo(pages_fts, 'pcnalx')
+		FROM pages_fts JOIN pages p ON p.id = pages_fts.rowid
+		WHERE pages_fts MATCH ?`+filters, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		var title sql.NullString
+		var crawledAt sql.NullTime
+		var info []byte
+		if err := rows.Scan(&hit.URL, &title, &crawledAt, &hit.Snippet, &info); err != nil {
+			return nil, err
+		}
+		hit.Title = title.String
+		hit.CrawledAt = crawledAt.Time
+		hit.Score = matchinfoBM25(info)
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// matchinfoBM25 computes the Okapi BM25 score of a row from FTS4
+// matchinfo(..., 'pcnalx') output
+func matchinfoBM25(info []byte) float64 {
+	const k1, b = 1.2, 0.75
+
+	v := make([]uint32, len(info)/4)
+	for i := range v {
+		v[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(v) < 3 {
+		return 0
+	}
+	phrases, columns, rows := int(v[0]), int(v[1]), float64(v[2])
+	if len(v) < 3+2*columns+3*phrases*columns {
+		return 0
+	}
+	avgLen, rowLen, hits := v[3:3+columns], v[3+columns:3+2*columns], v[3+2*columns:]
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < columns && c < len(columnWeights); c++ {
+			x := hits[3*(p*columns+c):]
+			tf, docs := float64(x[0]), float64(x[2])
+			if tf == 0 || avgLen[c] == 0 {
+				continue
+			}
+			idf := math.Log((rows - docs + 0.5) / (docs + 0.5))
+			if idf < 1e-6 {
+				idf = 1e-6
+			}
+			norm := 1 - b + b*float64(rowLen[c])/float64(avgLen[c])
+			score += columnWeights[c] * idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+	return score
+}
+
+// matchExpression turns a free-text query into an FTS match expression that
+// requires every word, or any word when anyWord is set, keeping a trailing *
+// as a prefix match. Punctuation and FTS operators in the input are dropped
+// so any query is valid.
+func matchExpression(query string, anyWord bool) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		prefix := strings.HasSuffix(word, "*")
+		before := len(terms)
+		for _, term := range strings.FieldsFunc(strings.ToLower(word), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			terms = append(terms, term)
+		}
+		if prefix && len(terms) > before {
+			terms[len(terms)-1] += "*"
+		}
+	}
+	if anyWord {
+		return strings.Join(terms, " OR ")
+	}
+	return strings.Join(terms, " ")
+}
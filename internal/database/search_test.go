@@ -0,0 +1,75 @@
+package database
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		query   string
+		anyWord bool
+		want    string
+	}{
+		{"", false, ""},
+		{"Install Linux", false, "install linux"},
+		{"install linux", true, "install OR linux"},
+		{"config*", false, "config*"},
+		{"ci/cd pipeline", false, "ci cd pipeline"},
+		{"node.js*", false, "node js*"},
+		{`"quoted" AND (NEAR)`, false, "quoted and near"},
+		{"-- * !", false, ""},
+		{"Größe ändern", false, "größe ändern"},
+	}
+
+	for _, tt := range tests {
+		if got := matchExpression(tt.query, tt.anyWord); got != tt.want {
+			t.Errorf("matchExpression(%q, %v) = %q, want %q", tt.query, tt.anyWord, got, tt.want)
+		}
+	}
+}
+
+// matchinfo encodes FTS4 matchinfo 'pcnalx' output for one phrase over the
+// title, headings and body columns
+func matchinfo(rows, docs uint32, avgLen, rowLen, tf [3]uint32) []byte {
+	v := []uint32{1, 3, rows}
+	v = append(v, avgLen[:]...)
+	v = append(v, rowLen[:]...)
+	for c := 0; c < 3; c++ {
+		v = append(v, tf[c], tf[c], docs)
+	}
+
+	info := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.NativeEndian.PutUint32(info[i*4:], x)
+	}
+	return info
+}
+
+func TestMatchinfoBM25(t *testing.T) {
+	lengths := [3]uint32{5, 20, 500}
+	inTitle := matchinfoBM25(matchinfo(100, 10, lengths, lengths, [3]uint32{1, 0, 0}))
+	inBody := matchinfoBM25(matchinfo(100, 10, lengths, lengths, [3]uint32{0, 0, 1}))
+	common := matchinfoBM25(matchinfo(100, 90, lengths, lengths, [3]uint32{0, 0, 1}))
+	frequent := matchinfoBM25(matchinfo(100, 10, lengths, lengths, [3]uint32{0, 0, 5}))
+
+	tests := []struct {
+		name string
+		got  float64
+		want func(float64) bool
+	}{
+		{"no hits", matchinfoBM25(matchinfo(100, 10, lengths, lengths, [3]uint32{})), func(s float64) bool { return s == 0 }},
+		{"empty", matchinfoBM25(nil), func(s float64) bool { return s == 0 }},
+		{"truncated", matchinfoBM25(matchinfo(100, 10, lengths, lengths, [3]uint32{1, 1, 1})[:20]), func(s float64) bool { return s == 0 }},
+		{"match", inBody, func(s float64) bool { return s > 0 }},
+		{"title outranks body", inTitle, func(s float64) bool { return s > inBody }},
+		{"rare term outranks common", inBody, func(s float64) bool { return s > common }},
+		{"more occurrences rank higher", frequent, func(s float64) bool { return s > inBody }},
+	}
+
+	for _, tt := range tests {
+		if !tt.want(tt.got) {
+			t.Errorf("%s: unexpected score %g", tt.name, tt.got)
+		}
+	}
+}
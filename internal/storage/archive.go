@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveStorage implements read-only Storage on top of a packed archive.
+// Archives are zip files, so the central directory serves as the index and
+// individual pages can be read without unpacking the whole archive.
+type ArchiveStorage struct {
+	path   string
+	reader *zip.ReadCloser
+	files  map[string]*zip.File
+}
+
+// Pack rolls the contents of an output directory into a single archive file.
+// Compressed files are stored under their uncompressed names so the archive
+// can be read the same way regardless of how the crawl was stored. It returns
+// the number of files written.
+func Pack(srcDir string, archivePath string) (int, error) {
+	absArchive, err := filepath.Abs(archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("invalid archive path: %w", err)
+	}
+
+	tmpPath := archivePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	w := zip.NewWriter(out)
+	count := 0
+
+	err = filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		if abs == absArchive || abs == absArchive+".tmp" {
+			return nil
+		}
+
+		// Skip SQLite transient files, they are only valid next to a live database
+		if strings.HasSuffix(p, "-journal") || strings.HasSuffix(p, "-wal") || strings.HasSuffix(p, "-shm") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		// Downloaded assets keep their original bytes, a .gz asset is not
+		// compressed page content
+		for _, ext := range compressedExts[1:] {
+			if strings.HasSuffix(name, ext) && !strings.HasPrefix(name, "assets/") {
+				if data, err = decompress(data, ext); err != nil {
+					return fmt.Errorf("failed to decompress %s: %w", name, err)
+				}
+				name = strings.TrimSuffix(name, ext)
+				break
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fw, err := w.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		w.Close()
+		out.Close()
+		return 0, fmt.Errorf("failed to pack %s: %w", srcDir, err)
+	}
+
+	if err := w.Close(); err != nil {
+		out.Close()
+		return 0, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return count, nil
+}
+
+// OpenArchive opens a packed archive for reading
+func OpenArchive(path string) (*ArchiveStorage, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		files[f.Name] = f
+	}
+
+	return &ArchiveStorage{path: path, reader: r, files: files}, nil
+}
+
+// DatabaseFile is the name of the crawl database in an output directory and
+// in a packed archive
+const DatabaseFile = "crawler.db"
+
+// DatabasePath returns the path of the crawl database of an output directory
+// or a packed archive. SQLite cannot read a database inside a zip, so the
+// database of an archive is extracted to a temporary file that the returned
+// cleanup function removes; the archive itself is never modified.
+func DatabasePath(path string) (string, func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("no crawl database found in %s", path)
+	}
+	if info.IsDir() {
+		dbPath := filepath.Join(path, DatabaseFile)
+		if _, err := os.Stat(dbPath); err != nil {
+			return "", nil, fmt.Errorf("no crawl database found in %s", path)
+		}
+		return dbPath, func() {}, nil
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	var dbFile *zip.File
+	for _, f := range r.File {
+		if f.Name == DatabaseFile {
+			dbFile = f
+			break
+		}
+	}
+	if dbFile == nil {
+		return "", nil, fmt.Errorf("no crawl database found in archive %s", path)
+	}
+
+	tmp, err := os.CreateTemp("", "stripper-*.db")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract crawl database: %w", err)
+	}
+	cleanup := func() {
+		for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+			os.Remove(tmp.Name() + suffix)
+		}
+	}
+
+	rc, err := dbFile.Open()
+	if err == nil {
+		_, err = io.Copy(tmp, rc)
+		rc.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to extract crawl database: %w", err)
+	}
+
+	return tmp.Name(), cleanup, nil
+}
+
+// IsArchive reports whether path is a packed archive rather than an output
+// directory
+func IsArchive(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Save is not supported, archives are read-only
+func (a *ArchiveStorage) Save(url string, content string, format string) error {
+	return fmt.Errorf("archive %s is read-only", a.path)
+}
+
+// Load reads the stored content for the given URL from the archive
+func (a *ArchiveStorage) Load(url string, format string) (string, error) {
+	f, ok := a.files[urlToFilename(url, format)]
+	if !ok {
+		return "", fmt.Errorf("no content found for URL: %s", url)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+
+	return string(data), nil
+}
+
+// HasContent checks if content exists for the given URL
+func (a *ArchiveStorage) HasContent(url string) bool {
+	_, err := a.GetLastCrawled(url)
+	return err == nil
+}
+
+// GetLastCrawled returns the last crawl time for the given URL
+func (a *ArchiveStorage) GetLastCrawled(url string) (time.Time, error) {
+	formats := []string{"markdown", "text", "html"}
+	var lastMod time.Time
+
+	for _, format := range formats {
+		f, ok := a.files[urlToFilename(url, format)]
+		if ok && (lastMod.IsZero() || f.Modified.After(lastMod)) {
+			lastMod = f.Modified
+		}
+	}
+
+	if lastMod.IsZero() {
+		return lastMod, fmt.Errorf("no content found for URL: %s", url)
+	}
+
+	return lastMod, nil
+}
+
+// Close closes the underlying archive file
+func (a *ArchiveStorage) Close() error {
+	return a.reader.Close()
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedExts lists the file suffixes checked when reading content, in
+// lookup order. The empty suffix is the uncompressed file.
+var compressedExts = []string{"", ".gz", ".zst"}
+
+// compressionExt returns the file suffix used for a compression mode
+func compressionExt(compression string) (string, error) {
+	switch compression {
+	case "", "none":
+		return "", nil
+	case "gzip":
+		return ".gz", nil
+	case "zstd":
+		return ".zst", nil
+	default:
+		return "", fmt.Errorf("unsupported compression: %s (use gzip or zstd)", compression)
+	}
+}
+
+// compress encodes data with the given compression mode
+func compress(data []byte, compression string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch compression {
+	case "", "none":
+		return data, nil
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zstd":
+		w, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress decodes data based on the suffix of the file it was read from
+func decompress(data []byte, ext string) ([]byte, error) {
+	switch ext {
+	case "":
+		return data, nil
+	case ".gz":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case ".zst":
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("unknown compressed file suffix: %s", ext)
+	}
+}
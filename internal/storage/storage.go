@@ -11,50 +11,127 @@ import (
 // Storage defines the interface for storing crawled content
 type Storage interface {
 	Save(url string, content string, format string) error
+	Load(url string, format string) (string, error)
 	HasContent(url string) bool
 	GetLastCrawled(url string) (time.Time, error)
+	Close() error
+}
+
+// Options configures the file storage
+type Options struct {
+	Compression string // "", "gzip" or "zstd"
 }
 
 // FileStorage implements Storage using the local filesystem
 type FileStorage struct {
-	baseDir string
+	baseDir     string
+	compression string
 }
 
 // NewFileStorage creates a new FileStorage instance
-func NewFileStorage(baseDir string) (*FileStorage, error) {
+func NewFileStorage(baseDir string, opts Options) (*FileStorage, error) {
+	if _, err := compressionExt(opts.Compression); err != nil {
+		return nil, err
+	}
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
-	return &FileStorage{baseDir: baseDir}, nil
+	return &FileStorage{baseDir: baseDir, compression: opts.Compression}, nil
+}
+
+// Open returns a read-capable Storage for either an output directory or a
+// packed archive file
+func Open(path string) (Storage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	if info.IsDir() {
+		return NewFileStorage(path, Options{})
+	}
+	return OpenArchive(path)
 }
 
 // Save stores the content to a file
 func (fs *FileStorage) Save(url string, content string, format string) error {
-	filename := fs.urlToFilename(url, format)
+	filename := urlToFilename(url, format)
 	fullPath := filepath.Join(fs.baseDir, filename)
 
 	// Create metadata
 	metadata := fmt.Sprintf("URL: %s\nDate: %s\n\n", url, time.Now().Format(time.RFC3339))
-	content = metadata + content
+	return fs.write(fullPath, metadata+content, fs.compression)
+}
+
+// Rewrite replaces previously stored content, including its metadata header,
+// with the given content as-is. The file keeps the compression it was
+// originally stored with.
+func (fs *FileStorage) Rewrite(url string, content string, format string) error {
+	fullPath := filepath.Join(fs.baseDir, urlToFilename(url, format))
+
+	compression := fs.compression
+	for _, c := range []string{"", "gzip", "zstd"} {
+		ext, _ := compressionExt(c)
+		if _, err := os.Stat(fullPath + ext); err == nil {
+			compression = c
+			break
+		}
+	}
+
+	return fs.write(fullPath, content, compression)
+}
+
+// write stores content at fullPath using the given compression
+func (fs *FileStorage) write(fullPath string, content string, compression string) error {
+	data, err := compress([]byte(content), compression)
+	if err != nil {
+		return fmt.Errorf("failed to compress content: %w", err)
+	}
+
+	ext, _ := compressionExt(compression)
 
 	// Write content to file
-	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(fullPath+ext, data, 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	// Remove copies stored with a different compression setting so reads
+	// always see the latest content
+	for _, other := range compressedExts {
+		if other != ext {
+			os.Remove(fullPath + other)
+		}
+	}
+
 	return nil
 }
 
-// HasContent checks if content exists for the given URL
-func (fs *FileStorage) HasContent(url string) bool {
-	formats := []string{"markdown", "text", "html"}
-	for _, format := range formats {
-		filename := fs.urlToFilename(url, format)
-		if _, err := os.Stat(filepath.Join(fs.baseDir, filename)); err == nil {
-			return true
+// Load reads the stored content for the given URL, decompressing it if needed
+func (fs *FileStorage) Load(url string, format string) (string, error) {
+	filename := urlToFilename(url, format)
+	fullPath := filepath.Join(fs.baseDir, filename)
+
+	for _, ext := range compressedExts {
+		data, err := os.ReadFile(fullPath + ext)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
 		}
+		data, err = decompress(data, ext)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress %s: %w", filename+ext, err)
+		}
+		return string(data), nil
 	}
-	return false
+
+	return "", fmt.Errorf("no content found for URL: %s", url)
+}
+
+// HasContent checks if content exists for the given URL
+func (fs *FileStorage) HasContent(url string) bool {
+	_, err := fs.GetLastCrawled(url)
+	return err == nil
 }
 
 // GetLastCrawled returns the last crawl time for the given URL
@@ -63,10 +140,12 @@ func (fs *FileStorage) GetLastCrawled(url string) (time.Time, error) {
 	var lastMod time.Time
 
 	for _, format := range formats {
-		filename := fs.urlToFilename(url, format)
-		info, err := os.Stat(filepath.Join(fs.baseDir, filename))
-		if err == nil && (lastMod.IsZero() || info.ModTime().After(lastMod)) {
-			lastMod = info.ModTime()
+		filename := urlToFilename(url, format)
+		for _, ext := range compressedExts {
+			info, err := os.Stat(filepath.Join(fs.baseDir, filename+ext))
+			if err == nil && (lastMod.IsZero() || info.ModTime().After(lastMod)) {
+				lastMod = info.ModTime()
+			}
 		}
 	}
 
@@ -77,8 +156,45 @@ func (fs *FileStorage) GetLastCrawled(url string) (time.Time, error) {
 	return lastMod, nil
 }
 
+// Close releases resources held by the storage
+func (fs *FileStorage) Close() error {
+	return nil
+}
+
+// StripMetadata removes the metadata header written by Save from stored content
+func StripMetadata(content string) string {
+	if !strings.HasPrefix(content, "URL: ") {
+		return content
+	}
+	if idx := strings.Index(content, "\n\n"); idx != -1 {
+		return content[idx+2:]
+	}
+	return content
+}
+
+// Filename returns the name, relative to the storage directory, under which
+// content for the given URL is stored (before any compression suffix)
+func Filename(url string, format string) string {
+	return urlToFilename(url, format)
+}
+
+// StoredFilename returns the name, relative to the storage directory, of the
+// file holding the content for the given URL, including the suffix of the
+// compression it is stored with. Content that is not stored yet is named
+// after the storage's compression setting.
+func (fs *FileStorage) StoredFilename(url string, format string) string {
+	filename := urlToFilename(url, format)
+	for _, ext := range compressedExts {
+		if _, err := os.Stat(filepath.Join(fs.baseDir, filename+ext)); err == nil {
+			return filename + ext
+		}
+	}
+	ext, _ := compressionExt(fs.compression)
+	return filename + ext
+}
+
 // urlToFilename converts a URL to a safe filename
-func (fs *FileStorage) urlToFilename(url string, format string) string {
+func urlToFilename(url string, format string) string {
 	// Remove scheme and query parameters
 	url = strings.TrimPrefix(url, "http://")
 	url = strings.TrimPrefix(url, "https://")
@@ -14,6 +14,23 @@ import (
 // TickMsg is sent when the stats should update
 type TickMsg time.Time
 
+// UsageMsg carries the running AI token and cost totals of the crawl
+type UsageMsg struct {
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Budget           float64 // 0 when no budget is set
+	BudgetExhausted  bool
+}
+
+// FetchMsg reports a page fetched by the crawler
+type FetchMsg struct {
+	URL           string
+	Size          int64
+	Duration      time.Duration // request to the site
+	ReaderLatency time.Duration // request to the Reader API
+}
+
 var (
 	titleStyle = lipgloss.NewStyle().
 			Bold(true).
@@ -30,11 +47,21 @@ type model struct {
 	db     *database.DB
 	width  int
 	height int
+	usage  UsageMsg
+
+	// Fetch throughput since the crawl started
+	started       time.Time
+	fetched       int
+	fetchedBytes  int64
+	readerFetches int
+	readerLatency time.Duration
+	slowest       FetchMsg
 }
 
 func New(db *database.DB) *tea.Program {
 	m := &model{
-		db: db,
+		db:      db,
+		started: time.Now(),
 	}
 	return tea.NewProgram(m)
 }
@@ -58,6 +85,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case TickMsg:
 		return m, nil
+
+	case UsageMsg:
+		m.usage = msg
+		return m, nil
+
+	case FetchMsg:
+		m.fetched++
+		m.fetchedBytes += msg.Size
+		if msg.ReaderLatency > 0 {
+			m.readerFetches++
+			m.readerLatency += msg.ReaderLatency
+		}
+		if msg.Duration+msg.ReaderLatency > m.slowest.Duration+m.slowest.ReaderLatency {
+			m.slowest = msg
+		}
+		return m, nil
 	}
 
 	return m, nil
@@ -74,22 +117,51 @@ func (m model) View() string {
 	b.WriteString(titleStyle.Render("Stripper - Web Content Crawler") + "\n\n")
 
 	// Stats
-	total, pending, completed, failed, err := m.db.GetStats()
+	total, pending, completed, failed, redirected, err := m.db.GetStats()
 	if err != nil {
 		b.WriteString(errorStyle.Render(fmt.Sprintf("Error getting stats: %v\n", err)))
 	} else {
+		done := completed + failed + redirected
 		progress := 0.0
 		if total > 0 {
-			progress = float64(completed+failed) / float64(total) * 100
+			progress = float64(done) / float64(total) * 100
 		}
 
-		b.WriteString(fmt.Sprintf("Progress: %.1f%% (%d/%d URLs)\n", progress, completed+failed, total))
+		b.WriteString(fmt.Sprintf("Progress: %.1f%% (%d/%d URLs)\n", progress, done, total))
 		b.WriteString(fmt.Sprintf("Status:\n"))
 		b.WriteString(fmt.Sprintf("  • Completed: %d\n", completed))
 		b.WriteString(fmt.Sprintf("  • Pending: %d\n", pending))
 		if failed > 0 {
 			b.WriteString(fmt.Sprintf("  • Failed: %d\n", failed))
 		}
+		if redirected > 0 {
+			b.WriteString(fmt.Sprintf("  • Redirected: %d\n", redirected))
+		}
+	}
+
+	// Throughput
+	if m.fetched > 0 {
+		minutes := time.Since(m.started).Minutes()
+		b.WriteString(fmt.Sprintf("\nThroughput: %.1f pages/min, %.1f KiB/s\n",
+			float64(m.fetched)/minutes, float64(m.fetchedBytes)/1024/(minutes*60)))
+		if m.readerFetches > 0 {
+			b.WriteString(fmt.Sprintf("  • Average Reader API latency: %s\n",
+				(m.readerLatency / time.Duration(m.readerFetches)).Round(time.Millisecond)))
+		}
+		b.WriteString(fmt.Sprintf("  • Slowest page: %s (%s)\n", urlStyle.Render(m.slowest.URL),
+			(m.slowest.Duration + m.slowest.ReaderLatency).Round(time.Millisecond)))
+	}
+
+	// AI usage
+	if tokens := m.usage.PromptTokens + m.usage.CompletionTokens; tokens > 0 {
+		b.WriteString(fmt.Sprintf("\nAI usage: %d tokens (%d prompt, %d completion), cost %.4f\n",
+			tokens, m.usage.PromptTokens, m.usage.CompletionTokens, m.usage.Cost))
+		if m.usage.Budget > 0 {
+			b.WriteString(fmt.Sprintf("  • Budget: %.4f\n", m.usage.Budget))
+		}
+		if m.usage.BudgetExhausted {
+			b.WriteString(errorStyle.Render("  • Budget reached, AI processing stopped") + "\n")
+		}
 	}
 
 	// Help
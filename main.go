@@ -4,7 +4,22 @@ import (
 	"fmt"
 	"os"
 
+	"stripper/cmd/ai"
+	"stripper/cmd/ask"
 	"stripper/cmd/crawl"
+	"stripper/cmd/db"
+	"stripper/cmd/digest"
+	"stripper/cmd/embed"
+	"stripper/cmd/export"
+	"stripper/cmd/llmstxt"
+	"stripper/cmd/pack"
+	"stripper/cmd/relink"
+	"stripper/cmd/runs"
+	"stripper/cmd/search"
+	"stripper/cmd/serve"
+	"stripper/cmd/status"
+	"stripper/cmd/summarize"
+	"stripper/cmd/translate"
 
 	"github.com/spf13/cobra"
 )
@@ -21,6 +36,21 @@ and other textual resources.`,
 
 	// Add commands
 	rootCmd.AddCommand(crawl.NewCrawlCmd())
+	rootCmd.AddCommand(pack.NewPackCmd())
+	rootCmd.AddCommand(relink.NewRelinkCmd())
+	rootCmd.AddCommand(llmstxt.NewLLMsTxtCmd())
+	rootCmd.AddCommand(export.NewExportCmd())
+	rootCmd.AddCommand(serve.NewServeCmd())
+	rootCmd.AddCommand(ai.NewAICmd())
+	rootCmd.AddCommand(status.NewStatusCmd())
+	rootCmd.AddCommand(summarize.NewSummarizeCmd())
+	rootCmd.AddCommand(embed.NewEmbedCmd())
+	rootCmd.AddCommand(search.NewSearchCmd())
+	rootCmd.AddCommand(ask.NewAskCmd())
+	rootCmd.AddCommand(digest.NewDigestCmd())
+	rootCmd.AddCommand(translate.NewTranslateCmd())
+	rootCmd.AddCommand(db.NewDBCmd())
+	rootCmd.AddCommand(runs.NewRunsCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)